@@ -1,9 +1,10 @@
 package flowdock
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -13,8 +14,10 @@ import (
 )
 
 const (
-	missMatchEmail = "404"
 	clientVersion = "1.1.7"
+	defaultAPIURL = "https://api.flowdock.com"
+	// time left after waiting for an invitation to be accepted
+	acceptanceWaitMargin = 30 * time.Second
 )
 
 // A Client is a Flowdock API client. It should be created
@@ -24,6 +27,12 @@ type Client struct {
 	// HTTP client used to communicate with the API.
 	Http *http.Client
 	URL  string
+
+	// set when authenticating as an OAuth application instead of with ApiKey
+	oauth *oauthCredentials
+
+	limiter *rateLimiter
+	users   *userCache
 }
 
 // NewClient creates a new Client and automatically fetches
@@ -31,48 +40,167 @@ func NewClient(apiKey string) (*Client, error) {
 	if len(strings.TrimSpace(apiKey)) == 0 {
 		return nil, fmt.Errorf("can't run with an empty token")
 	}
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	client.ApiKey = apiKey
+	registerSecret(apiKey)
+	return client, nil
+}
+
+// newClient sets up everything but the credentials.
+func newClient() (*Client, error) {
+	limiter := newRateLimiter(defaultRequestsPerSecond, defaultBurst)
+	transport := &rateLimitTransport{base: http.DefaultTransport, limiter: limiter}
 	client := &Client{
-		ApiKey: apiKey,
-		Http:   &http.Client{Timeout: 10 * time.Second},
-		URL:    fmt.Sprintf("https://%s@api.flowdock.com", apiKey),
+		// timeouts are applied per attempt by the retrying transport
+		Http:    &http.Client{Transport: newRetryTransport(transport)},
+		limiter: limiter,
+		users:   newUserCache(defaultUserCacheTTL),
+	}
+	if err := client.setBaseURL(defaultAPIURL); err != nil {
+		return nil, err
 	}
 	return client, nil
 }
 
-func (client *Client) getUserById(userId string) (*User, error) {
-	url := fmt.Sprintf("%s/users/%s", client.URL, userId)
-	res, err := client.Http.Get(url)
+// setBaseURL points the client at another API endpoint, e.g. a recording
+// proxy or a local stub.
+func (client *Client) setBaseURL(apiURL string) error {
+	base, err := url.Parse(strings.TrimRight(apiURL, "/"))
 	if err != nil {
-		log.Printf("getUserById error:%s", err.Error())
-		return nil, fmt.Errorf("getUserById http request error: %s", userId)
+		return fmt.Errorf("invalid api_url %q: %w", apiURL, err)
 	}
-	defer res.Body.Close()
-	user := &User{}
-	json.NewDecoder(res.Body).Decode(user)
-	if user.ID == 0 {
-		return nil, fmt.Errorf("no matching user with userId %s", userId)
+	if (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return fmt.Errorf("invalid api_url %q, expected an http(s) url", apiURL)
 	}
-	return user, nil
+	// the token is sent in the Authorization header, never in the url
+	if base.User != nil {
+		return fmt.Errorf("api_url must not contain credentials, use api_token instead")
+	}
+	client.URL = base.String()
+	return nil
 }
 
-func (client *Client) getInvitationByInviteId(org string, flow string, inviteId string) (*Invitation, error) {
-	url := fmt.Sprintf("%s/flows/%s/%s/invitations/%s", client.URL, org, flow, inviteId)
+// setRateLimit changes how many requests per second the client sends,
+// requestsPerSecond 0 disables the limiter.
+func (client *Client) setRateLimit(requestsPerSecond float64, burst int) {
+	client.limiter.configure(requestsPerSecond, burst)
+}
 
-	res, err := client.Http.Get(url)
+// setRetryPolicy changes how often and how long failed requests are retried,
+// maxRetries 0 disables retries.
+func (client *Client) setRetryPolicy(maxRetries int, minWait time.Duration, maxWait time.Duration) {
+	if transport, ok := client.Http.Transport.(*retryTransport); ok {
+		transport.maxRetries = maxRetries
+		transport.minWait = minWait
+		transport.maxWait = maxWait
+	}
+}
+
+// configureHTTP applies the connection settings to every request the client
+// sends.
+func (client *Client) configureHTTP(settings httpSettings) error {
+	transport, err := newHTTPTransport(settings)
 	if err != nil {
-		log.Printf("getInvitationByInviteId error:%s", err.Error())
-		return nil, fmt.Errorf("getInvitationByInviteId error")
+		return err
 	}
-	defer res.Body.Close()
+	retry, ok := client.Http.Transport.(*retryTransport)
+	if !ok {
+		return fmt.Errorf("unexpected HTTP transport %T", client.Http.Transport)
+	}
+	retry.timeout = settings.Timeout
+	if limited, ok := retry.base.(*rateLimitTransport); ok {
+		limited.base = transport
+	} else {
+		retry.base = transport
+	}
+	return nil
+}
+
+func (client *Client) getUserById(ctx context.Context, userId string) (*User, error) {
+	url := fmt.Sprintf("%s/users/%s", client.URL, userId)
+	user := &User{}
+	if err := client.sendRequest(ctx, "GET", url, nil, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (client *Client) getInvitationByInviteId(ctx context.Context, org string, flow string, inviteId string) (*Invitation, error) {
+	url := fmt.Sprintf("%s/flows/%s/%s/invitations/%s", client.URL, org, flow, inviteId)
 	invitation := &Invitation{}
-	json.NewDecoder(res.Body).Decode(invitation)
-	if invitation.ID == 0 {
-		return nil, fmt.Errorf("no matching invitation with inviteId %s", inviteId)
+	if err := client.sendRequest(ctx, "GET", url, nil, invitation); err != nil {
+		return nil, err
 	}
 	return invitation, nil
 }
 
-func (client *Client) inviteNewUser(email string, message string,
+// listInvitations returns the pending and accepted invitations of a flow.
+func (client *Client) listInvitations(ctx context.Context, org string, flow string) ([]Invitation, error) {
+	url := fmt.Sprintf("%s/flows/%s/%s/invitations", client.URL, org, flow)
+	var invitations []Invitation
+	if err := client.sendRequest(ctx, "GET", url, nil, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// waitForInvitationAccepted polls the invitation every interval until it has
+// been accepted, giving up after timeout or when it is revoked. The wait ends
+// early enough before the deadline of ctx to let the caller refresh its state.
+func (client *Client) waitForInvitationAccepted(ctx context.Context, org string, flow string, inviteId string,
+	timeout time.Duration, interval time.Duration) (*Invitation, error) {
+
+	limit := ""
+	if deadline, ok := ctx.Deadline(); ok {
+		left := time.Until(deadline)
+		margin := acceptanceWaitMargin
+		if left < 2*margin {
+			margin = left / 2
+		}
+		if left-margin < timeout {
+			timeout = left - margin
+			if timeout > time.Second {
+				timeout = timeout.Truncate(time.Second)
+			}
+			limit = ", the operation timeout is too short for acceptance_timeout"
+		}
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		invitation, err := client.getInvitationByInviteId(waitCtx, org, flow, inviteId)
+		switch {
+		case err != nil && waitCtx.Err() == nil:
+			return nil, err
+		case err == nil && invitation.State == invitationAccepted:
+			// the new member isn't in the cached user list yet
+			client.users.invalidate(org)
+			return invitation, nil
+		case err == nil && invitation.State != invitationPending:
+			return nil, fmt.Errorf("invitation %s for %s is %s and can't be accepted anymore",
+				inviteId, invitation.Email, invitation.State)
+		}
+		log.Printf("[DEBUG] invitation %s in %s/%s is still pending, checking again in %s", inviteId, org, flow, interval)
+
+		select {
+		case <-ticker.C:
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("invitation %s in %s/%s was not accepted within %s%s",
+				inviteId, org, flow, timeout, limit)
+		}
+	}
+}
+
+func (client *Client) inviteNewUser(ctx context.Context, email string, message string,
 	org string, flow string) (*Invitation, error) {
 
 	params := url.Values{
@@ -81,15 +209,10 @@ func (client *Client) inviteNewUser(email string, message string,
 	}
 	url := fmt.Sprintf("%s/flows/%s/%s/invitations", client.URL, org, flow)
 
-	res, error := client.Http.PostForm(url, params)
-	if error != nil {
-		log.Printf("inviteNewUser http request error: %s", error)
-	}
-	defer res.Body.Close()
-
 	invitation := &Invitation{}
-	json.NewDecoder(res.Body).Decode(invitation)
-
+	if err := client.sendRequest(ctx, "POST", url, params, invitation); err != nil {
+		return nil, fmt.Errorf("inviteNewUser failed: %w", err)
+	}
 	if invitation.ID == 0 {
 		return nil, fmt.Errorf("inviteNewUser error, invitation id=0, response: %s", invitation.MESSAGE)
 	}
@@ -97,64 +220,356 @@ func (client *Client) inviteNewUser(email string, message string,
 	return invitation, nil
 }
 
-func (client *Client) deleteUserFromOrg(org string, id string) error {
+func (client *Client) deleteUserFromOrg(ctx context.Context, org string, id string) error {
 	url := fmt.Sprintf("%s/organizations/%s/users/%s", client.URL, org, id)
-	log.Printf("url:%s", url)
-	result := client.deleteByUrl(url)
+	log.Printf("[DEBUG] removing user %s from org %s", id, org)
+	result := client.deleteByUrl(ctx, url)
+	client.users.invalidate(org)
 	return result
 }
-func (client *Client) deleteInvitationById(org string, flow string, id string) error {
+func (client *Client) deleteInvitationById(ctx context.Context, org string, flow string, id string) error {
 	url := fmt.Sprintf("%s/flows/%s/%s/invitations/%s", client.URL, org, flow, id)
-	result := client.deleteByUrl(url)
+	result := client.deleteByUrl(ctx, url)
 	return result
 }
 
-func (client *Client) deleteByUrl(url string) error {
-	// Create request
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		log.Printf("user Delete failed")
-		return fmt.Errorf("user Delete failed")
+// resendInvitation replaces a pending invitation with a new one, which sends
+// the email again.
+func (client *Client) resendInvitation(ctx context.Context, org string, flow string, inviteId string,
+	email string, message string) (*Invitation, error) {
+
+	err := client.deleteInvitationById(ctx, org, flow, inviteId)
+	if err != nil && !IsNotFound(err) {
+		return nil, fmt.Errorf("revoking invitation %s failed: %w", inviteId, err)
 	}
-	// Fetch Request
-	res, err := client.Http.Do(req)
-	if err != nil || res.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("delete request failed or user not found!")
+	return client.inviteNewUser(ctx, email, message, org, flow)
+}
+
+func (client *Client) deleteByUrl(ctx context.Context, url string) error {
+	return client.sendRequest(ctx, "DELETE", url, nil, nil)
+}
+
+func (client *Client) getFlow(ctx context.Context, org string, flow string) (*Flow, error) {
+	url := fmt.Sprintf("%s/flows/%s/%s", client.URL, org, flow)
+	result := &Flow{}
+	if err := client.sendRequest(ctx, "GET", url, nil, result); err != nil {
+		return nil, err
 	}
-	defer res.Body.Close()
+	return result, nil
+}
 
-	return nil
+// listFlows returns every flow visible to the token, including the ones the
+// token owner hasn't joined.
+func (client *Client) listFlows(ctx context.Context) ([]Flow, error) {
+	url := fmt.Sprintf("%s/flows/all", client.URL)
+	var flows []Flow
+	if err := client.sendRequest(ctx, "GET", url, nil, &flows); err != nil {
+		return nil, err
+	}
+	return flows, nil
 }
 
-func (client *Client) getUserIdByEmail(org string, email string) (string, error) {
-	var url = fmt.Sprintf("%s/organizations/%s/users", client.URL, org)
+func (client *Client) createFlow(ctx context.Context, org string, name string) (*Flow, error) {
+	params := url.Values{
+		"name": {name},
+	}
+	url := fmt.Sprintf("%s/flows/%s", client.URL, org)
+	result := &Flow{}
+	if err := client.sendRequest(ctx, "POST", url, params, result); err != nil {
+		return nil, fmt.Errorf("createFlow failed: %w", err)
+	}
+	if len(result.ID) == 0 {
+		return nil, fmt.Errorf("createFlow error, empty flow id, response: %s", result.MESSAGE)
+	}
+	return result, nil
+}
 
-	res, errHttp := client.Http.Get(url)
-	if errHttp != nil {
-		log.Printf("getUserIdByEmail http request error:%s", errHttp.Error())
-		return "", errHttp
+// updateFlow sends the given attributes to PUT /flows/:org/:flow and returns
+// the updated flow. Archiving a flow is an update with disabled=true.
+func (client *Client) updateFlow(ctx context.Context, org string, flow string, params url.Values) (*Flow, error) {
+	url := fmt.Sprintf("%s/flows/%s/%s", client.URL, org, flow)
+	result := &Flow{}
+	if err := client.sendRequest(ctx, "PUT", url, params, result); err != nil {
+		return nil, err
 	}
-	defer res.Body.Close()
+	return result, nil
+}
 
+func (client *Client) archiveFlow(ctx context.Context, org string, flow string) error {
+	_, err := client.updateFlow(ctx, org, flow, url.Values{"disabled": {"true"}})
+	return err
+}
+
+func (client *Client) getOrganization(ctx context.Context, org string) (*Organization, error) {
+	url := fmt.Sprintf("%s/organizations/%s", client.URL, org)
+	result := &Organization{}
+	if err := client.sendRequest(ctx, "GET", url, nil, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (client *Client) updateOrganization(ctx context.Context, org string, params url.Values) (*Organization, error) {
+	url := fmt.Sprintf("%s/organizations/%s", client.URL, org)
+	result := &Organization{}
+	if err := client.sendRequest(ctx, "PUT", url, params, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (client *Client) listFlowUsers(ctx context.Context, org string, flow string) ([]User, error) {
+	url := fmt.Sprintf("%s/flows/%s/%s/users", client.URL, org, flow)
 	var users []User
-	body, errIO := ioutil.ReadAll(res.Body)
-	if errIO != nil {
-		log.Printf("getUserIdByEmail ioutil.ReadAll encoding error response body: %s", res.Body)
-		return "", errIO
+	if err := client.sendRequest(ctx, "GET", url, nil, &users); err != nil {
+		return nil, err
 	}
+	return users, nil
+}
 
-	errorEncode := json.Unmarshal(body, &users)
-	if errorEncode != nil {
-		log.Printf("unexpected encoding error: %s", body)
-		return "", fmt.Errorf("unexpected encoding error:%s", body)
+func (client *Client) addUserToFlow(ctx context.Context, org string, flow string, userId string) error {
+	params := url.Values{
+		"id": {userId},
+	}
+	url := fmt.Sprintf("%s/flows/%s/%s/users", client.URL, org, flow)
+	req, err := client.newRequest(ctx, "POST", url, params)
+	if err != nil {
+		return err
+	}
+	// adding a user who is already in the flow changes nothing
+	return client.doRequest(withSafeRetry(req), nil)
+}
+
+// removeUserFromFlow only removes the user from the given flow, the user
+// stays a member of the organization.
+func (client *Client) removeUserFromFlow(ctx context.Context, org string, flow string, userId string) error {
+	url := fmt.Sprintf("%s/flows/%s/%s/users/%s", client.URL, org, flow, userId)
+	return client.sendRequest(ctx, "DELETE", url, nil, nil)
+}
+
+func (client *Client) getSource(ctx context.Context, org string, flow string, sourceId string) (*Source, error) {
+	url := fmt.Sprintf("%s/flows/%s/%s/sources/%s", client.URL, org, flow, sourceId)
+	result := &Source{}
+	if err := client.sendRequest(ctx, "GET", url, nil, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (client *Client) createSource(ctx context.Context, org string, flow string, params url.Values) (*Source, error) {
+	url := fmt.Sprintf("%s/flows/%s/%s/sources", client.URL, org, flow)
+	result := &Source{}
+	if err := client.sendRequest(ctx, "POST", url, params, result); err != nil {
+		return nil, fmt.Errorf("createSource failed: %w", err)
+	}
+	if result.ID == 0 {
+		return nil, fmt.Errorf("createSource error, source id=0, response: %s", result.MESSAGE)
+	}
+	return result, nil
+}
+
+func (client *Client) deleteSource(ctx context.Context, org string, flow string, sourceId string) error {
+	url := fmt.Sprintf("%s/flows/%s/%s/sources/%s", client.URL, org, flow, sourceId)
+	return client.sendRequest(ctx, "DELETE", url, nil, nil)
+}
+
+func (client *Client) getWebhook(ctx context.Context, org string, flow string, webhookId string) (*Webhook, error) {
+	url := fmt.Sprintf("%s/flows/%s/%s/webhooks/%s", client.URL, org, flow, webhookId)
+	result := &Webhook{}
+	if err := client.sendRequest(ctx, "GET", url, nil, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (client *Client) createWebhook(ctx context.Context, org string, flow string, params url.Values) (*Webhook, error) {
+	url := fmt.Sprintf("%s/flows/%s/%s/webhooks", client.URL, org, flow)
+	result := &Webhook{}
+	if err := client.sendRequest(ctx, "POST", url, params, result); err != nil {
+		return nil, fmt.Errorf("createWebhook failed: %w", err)
+	}
+	if result.ID == 0 {
+		return nil, fmt.Errorf("createWebhook error, webhook id=0, response: %s", result.MESSAGE)
+	}
+	return result, nil
+}
+
+func (client *Client) updateWebhook(ctx context.Context, org string, flow string, webhookId string, params url.Values) (*Webhook, error) {
+	url := fmt.Sprintf("%s/flows/%s/%s/webhooks/%s", client.URL, org, flow, webhookId)
+	result := &Webhook{}
+	if err := client.sendRequest(ctx, "PUT", url, params, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (client *Client) deleteWebhook(ctx context.Context, org string, flow string, webhookId string) error {
+	url := fmt.Sprintf("%s/flows/%s/%s/webhooks/%s", client.URL, org, flow, webhookId)
+	return client.sendRequest(ctx, "DELETE", url, nil, nil)
+}
+
+// listOrgUsers is served from the user cache, see setUserCacheTTL.
+func (client *Client) listOrgUsers(ctx context.Context, org string) ([]User, error) {
+	return client.users.get(org, func() ([]User, error) {
+		url := fmt.Sprintf("%s/organizations/%s/users", client.URL, org)
+		var users []User
+		if err := client.sendRequest(ctx, "GET", url, nil, &users); err != nil {
+			return nil, err
+		}
+		return users, nil
+	})
+}
+
+// setUserCacheTTL changes how long organization users are cached, ttl 0
+// disables the cache.
+func (client *Client) setUserCacheTTL(ttl time.Duration) {
+	client.users.setTTL(ttl)
+}
+
+// getOrgUserById returns an APIError for which IsNotFound is true when the
+// user isn't a member of the org.
+func (client *Client) getOrgUserById(ctx context.Context, org string, userId string) (*User, error) {
+	users, err := client.listOrgUsers(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if strconv.FormatInt(user.ID, 10) == userId {
+			return &user, nil
+		}
+	}
+	return nil, &APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("no user with id %s", userId),
+		Method:     "GET",
+		Endpoint:   fmt.Sprintf("/organizations/%s/users", org),
+	}
+}
+
+func (client *Client) setOrgUserAdmin(ctx context.Context, org string, userId string, admin bool) error {
+	params := url.Values{
+		"admin": {strconv.FormatBool(admin)},
+	}
+	url := fmt.Sprintf("%s/organizations/%s/users/%s", client.URL, org, userId)
+	err := client.sendRequest(ctx, "PUT", url, params, nil)
+	client.users.invalidate(org)
+	return err
+}
+
+// getUserIdByEmail returns an APIError for which IsNotFound is true when
+// nobody in the org uses the email.
+func (client *Client) getUserIdByEmail(ctx context.Context, org string, email string) (string, error) {
+	users, err := client.listOrgUsers(ctx, org)
+	if err != nil {
+		log.Printf("getUserIdByEmail request error:%s", err.Error())
+		return "", err
 	}
 
 	for _, user := range users {
 		log.Printf("unmarshalled users, userId:%d, email:%s", user.ID, user.Email)
-		if user.Email == email {
+		if strings.EqualFold(user.Email, email) {
 			return strconv.FormatInt(user.ID, 10), nil
 		}
 	}
 	log.Printf("getUserIdByEmail didn't find matching email:%s in org:%s", email, org)
-	return "", fmt.Errorf(missMatchEmail)
+	return "", &APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("no user with email %s", email),
+		Method:     "GET",
+		Endpoint:   fmt.Sprintf("/organizations/%s/users", org),
+	}
+}
+
+// sendRequest sends params as a form to endpoint and decodes a JSON response
+// into out when it is not nil. Non-2xx responses are returned as an *APIError
+// carrying the message returned by Flowdock.
+func (client *Client) sendRequest(ctx context.Context, method string, endpoint string, params url.Values, out interface{}) error {
+	req, err := client.newRequest(ctx, method, endpoint, params)
+	if err != nil {
+		return err
+	}
+	return client.doRequest(req, out)
+}
+
+func (client *Client) newRequest(ctx context.Context, method string, endpoint string, params url.Values) (*http.Request, error) {
+	var body io.Reader
+	if params != nil {
+		body = strings.NewReader(params.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, &redactedError{err}
+	}
+	if err := client.authorize(req); err != nil {
+		return nil, err
+	}
+	if params != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return req, nil
+}
+
+// authorize sets the Authorization header of req, OAuth access tokens are
+// sent as bearer tokens and personal API tokens as the basic auth user name.
+func (client *Client) authorize(req *http.Request) error {
+	if client.oauth == nil {
+		req.SetBasicAuth(client.ApiKey, "")
+		return nil
+	}
+	accessToken, err := client.oauth.accessTokenFor(req.Context(), client)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	return nil
+}
+
+func (client *Client) doRequest(req *http.Request, out interface{}) error {
+	method := req.Method
+	res, err := client.Http.Do(req)
+	// an access token may be revoked before it expires, refresh it once
+	if err == nil && res.StatusCode == http.StatusUnauthorized && client.oauth != nil &&
+		(req.Body == nil || req.GetBody != nil) {
+		res.Body.Close()
+		client.oauth.expire(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			if retry.Body, err = req.GetBody(); err != nil {
+				return err
+			}
+		}
+		if err := client.authorize(retry); err != nil {
+			return err
+		}
+		res, err = client.Http.Do(retry)
+	}
+	if err != nil {
+		err = &redactedError{err}
+		log.Printf("%s request error:%s", method, err.Error())
+		return fmt.Errorf("%s request failed: %w", method, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		apiError := &APIError{
+			StatusCode: res.StatusCode,
+			Method:     method,
+			Endpoint:   req.URL.Path,
+			RequestID:  res.Header.Get("X-Request-Id"),
+		}
+		errorBody := &struct {
+			MESSAGE string `json:"message"`
+		}{}
+		if json.NewDecoder(res.Body).Decode(errorBody) == nil {
+			apiError.Message = errorBody.MESSAGE
+		}
+		return apiError
+	}
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("unexpected encoding error in %s %s response: %w", method, req.URL.Path, err)
+	}
+	return nil
 }
@@ -1,12 +1,19 @@
 package flowdock
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -23,7 +30,7 @@ func Test_Flowdock_Init_NewClient_When_System_First_Launched(t *testing.T) {
 			name:           "Init client with apiKey",
 			args:           "test",
 			ExpectedApiKey: "test",
-			ExpectedURL:    "https://test@api.flowdock.com",
+			ExpectedURL:    "https://api.flowdock.com",
 			expectError:    false,
 		},
 		{
@@ -42,8 +49,9 @@ func Test_Flowdock_Init_NewClient_When_System_First_Launched(t *testing.T) {
 				return
 			}
 			if got != nil {
-				assert.Equal(t, cc.ExpectedApiKey, "test", "apiKey should be equal")
-				assert.Equal(t, cc.ExpectedURL, "https://test@api.flowdock.com", "URL should be equal")
+				assert.Equal(t, cc.ExpectedApiKey, got.ApiKey, "apiKey should be equal")
+				assert.Equal(t, cc.ExpectedURL, got.URL, "URL should be equal")
+				assert.NotContains(t, got.URL, cc.ExpectedApiKey, "the token must not be part of the URL")
 			}
 		})
 	}
@@ -58,9 +66,10 @@ func Test_inviteNewUser_Should_Return_Error_When_Get_AccessDenied_From_Server(t
 	}))
 	defer ts.Close()
 	client.URL = ts.URL
-	_, err := client.inviteNewUser("xxxxxxx@fairfaxmedia.co.nz",
+	_, err := client.inviteNewUser(context.Background(), "xxxxxxx@fairfaxmedia.co.nz",
 		"message", "org", "flow")
 	assert.Error(t, err)
+	assert.True(t, IsForbidden(err))
 }
 
 func inviteNewUserMockAccessDenied() string {
@@ -77,7 +86,7 @@ func Test_Should_Ship_Invitation_With_Valid_Email_Org_Flow(t *testing.T) {
 	defer ts.Close()
 
 	client.URL = ts.URL
-	result, err := client.inviteNewUser("xxxxxxx@fairfaxmedia.co.nz",
+	result, err := client.inviteNewUser(context.Background(), "xxxxxxx@fairfaxmedia.co.nz",
 		"message", "org", "flow")
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1413413), result.ID)
@@ -108,7 +117,8 @@ func Test_Should_Trigger_Http_Delete_When_Given_Org_And_UserId(t *testing.T) {
 	}))
 	defer ts.Close()
 	client.URL = ts.URL + client.URL
-	client.deleteUserFromOrg(org, id)
+	client.setRetryPolicy(0, 0, 0)
+	client.deleteUserFromOrg(context.Background(), org, id)
 }
 
 func Test_Should_Delete_User_Success_When_Given_Valid_URL(t *testing.T) {
@@ -116,10 +126,10 @@ func Test_Should_Delete_User_Success_When_Given_Valid_URL(t *testing.T) {
 
 	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
 		res.WriteHeader(http.StatusNoContent)
-		fmt.Printf(req.URL.RawPath)
+		fmt.Print(req.URL.RawPath)
 	}))
 	defer ts.Close()
-	result := client.deleteByUrl(ts.URL)
+	result := client.deleteByUrl(context.Background(), ts.URL)
 
 	assert.NoError(t, result)
 }
@@ -132,8 +142,9 @@ func Test_Should_Delete_User_Failed_When_Get_Error_From_Server(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	result := client.deleteByUrl(ts.URL)
+	result := client.deleteByUrl(context.Background(), ts.URL)
 	assert.Error(t, result)
+	assert.True(t, IsNotFound(result))
 }
 
 func deleteUserFromOrgMockNotFound() string {
@@ -173,14 +184,15 @@ func Test_Should_getUserId_By_Email_And_Org_When_User_Exists_Or_Empty_If_Not(t *
 	defer ts.Close()
 	client.URL = ts.URL
 
-	result, _ := client.getUserIdByEmail("org", "xxxxx@fairfaxmedia.co.nz")
+	result, _ := client.getUserIdByEmail(context.Background(), "org", "xxxxx@fairfaxmedia.co.nz")
 	assert.Equal(t, "123456", result)
 
-	result1, _ := client.getUserIdByEmail("org", "yyyyy@fairfaxmedia.co.nz")
+	result1, _ := client.getUserIdByEmail(context.Background(), "org", "yyyyy@fairfaxmedia.co.nz")
 	assert.Equal(t, "654321", result1)
 
-	noResult, _ := client.getUserIdByEmail("org", "zzzzz@fairfaxmedia.co.nz")
+	noResult, err := client.getUserIdByEmail(context.Background(), "org", "zzzzz@fairfaxmedia.co.nz")
 	assert.Equal(t, "", noResult)
+	assert.True(t, IsNotFound(err))
 }
 
 func Test_getUserIdByEmail_Should_Get_Error_When_Internal_Server_Error_Happens(t *testing.T) {
@@ -191,9 +203,350 @@ func Test_getUserIdByEmail_Should_Get_Error_When_Internal_Server_Error_Happens(t
 	}))
 	defer ts.Close()
 	client.URL = ts.URL
-	result, err := client.getUserIdByEmail("org", "zzzzz@fairfaxmedia.co.nz")
+	client.setRetryPolicy(0, 0, 0)
+	result, err := client.getUserIdByEmail(context.Background(), "org", "zzzzz@fairfaxmedia.co.nz")
 
 	assert.Error(t, err)
+	assert.False(t, IsNotFound(err))
 	assert.Equal(t, "", result)
 
 }
+
+func Test_getUserIdByEmail_Should_Ignore_Case_Of_Email(t *testing.T) {
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(`[{"id": 123456, "email": "Xxxxx@FairfaxMedia.co.nz"}]`))
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+	result, err := client.getUserIdByEmail(context.Background(), "org", "xxxxx@fairfaxmedia.co.nz")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "123456", result)
+}
+
+func Test_createFlow_Should_Post_Name_To_Org_Flows(t *testing.T) {
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/flows/org", req.URL.Path)
+		req.ParseForm()
+		assert.Equal(t, "Ops Projects", req.PostForm.Get("name"))
+		res.WriteHeader(http.StatusCreated)
+		res.Write([]byte(flowMockBasic()))
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+
+	result, err := client.createFlow(context.Background(), "org", "Ops Projects")
+	assert.NoError(t, err)
+	assert.Equal(t, "deadbeefdeadbeef", result.ID)
+	assert.Equal(t, "ops-projects", result.APIName)
+}
+
+func Test_getFlow_Should_Return_NotFound_When_Flow_Is_Missing(t *testing.T) {
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusNotFound)
+		res.Write([]byte(deleteUserFromOrgMockNotFound()))
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+
+	_, err := client.getFlow(context.Background(), "org", "ops-projects")
+	assert.True(t, IsNotFound(err))
+}
+
+func flowMockBasic() string {
+	return fmt.Sprintf(`
+	{
+		"id": "deadbeefdeadbeef",
+		"name": "Ops Projects",
+		"parameterized_name": "ops-projects",
+		"description": "",
+		"access_mode": "invitation",
+		"open": true,
+		"joined": true,
+		"disabled": false,
+		"url": "https://api.flowdock.com/flows/org/ops-projects",
+		"web_url": "https://www.flowdock.com/app/org/ops-projects",
+		"organization": {"id": 42, "parameterized_name": "org", "name": "Org"}
+	}
+	`)
+}
+
+func Test_getOrganization_Should_Decode_Settings_And_Subscription(t *testing.T) {
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/organizations/test-terraform", req.URL.Path)
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte(organizationMockBasic()))
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+
+	result, err := client.getOrganization(context.Background(), "test-terraform")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), result.ID)
+	assert.Equal(t, "Test Terraform", result.Name)
+	assert.Equal(t, 50, result.UserLimit)
+	assert.Equal(t, true, result.Subscription.Trial)
+	assert.Equal(t, 2, len(result.Users))
+	assert.Equal(t, true, result.Users[0].Admin)
+	assert.Equal(t, false, result.Users[1].Admin)
+}
+
+func organizationMockBasic() string {
+	return fmt.Sprintf(`
+	{
+		"id": 42,
+		"parameterized_name": "test-terraform",
+		"name": "Test Terraform",
+		"user_limit": 50,
+		"user_count": 2,
+		"active": true,
+		"url": "https://api.flowdock.com/organizations/test-terraform",
+		"subscription": {"trial": true, "trial_ends": "2020-01-31"},
+		"users": [
+			{"id": 123456, "email": "xxxxx@fairfaxmedia.co.nz", "name": "xxxxx", "nick": "xxxxx", "admin": true},
+			{"id": 654321, "email": "yyyyy@fairfaxmedia.co.nz", "name": "yyyyy", "nick": "yyyyy", "admin": false}
+		]
+	}
+	`)
+}
+
+func Test_listFlows_Should_Return_All_Flows_Visible_To_Token(t *testing.T) {
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/flows/all", req.URL.Path)
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte("[" + flowMockBasic() + "]"))
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+
+	result, err := client.listFlows(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, "ops-projects", result[0].APIName)
+}
+
+func Test_removeUserFromFlow_Should_Delete_Flow_User_Not_Org_User(t *testing.T) {
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "DELETE", req.Method)
+		assert.Equal(t, "/flows/org1/flow1/users/123456", req.URL.Path)
+		res.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+
+	err := client.removeUserFromFlow(context.Background(), "org1", "flow1", "123456")
+	assert.NoError(t, err)
+}
+
+func Test_setOrgUserAdmin_Should_Put_Admin_Flag(t *testing.T) {
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "PUT", req.Method)
+		assert.Equal(t, "/organizations/org1/users/123456", req.URL.Path)
+		req.ParseForm()
+		assert.Equal(t, "true", req.PostForm.Get("admin"))
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte(`{"id": 123456, "admin": true}`))
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+
+	err := client.setOrgUserAdmin(context.Background(), "org1", "123456", true)
+	assert.NoError(t, err)
+}
+
+func Test_createSource_Should_Return_Source_With_Flow_Token(t *testing.T) {
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/flows/org1/flow1/sources", req.URL.Path)
+		req.ParseForm()
+		assert.Equal(t, "CI", req.PostForm.Get("name"))
+		res.WriteHeader(http.StatusCreated)
+		res.Write([]byte(`{"id": 321, "name": "CI", "flow_token": "secret-token", "application": {"id": 7, "name": "Jenkins"}}`))
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+
+	result, err := client.createSource(context.Background(), "org1", "flow1", url.Values{"name": {"CI"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(321), result.ID)
+	assert.Equal(t, "secret-token", result.FlowToken)
+	assert.Equal(t, int64(7), result.Application.ID)
+}
+
+func Test_createWebhook_Should_Post_Url_And_Event_Filters(t *testing.T) {
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/flows/org1/flow1/webhooks", req.URL.Path)
+		req.ParseForm()
+		assert.Equal(t, "https://bot.example.com/hook", req.PostForm.Get("url"))
+		assert.Equal(t, []string{"message", "comment"}, req.PostForm["events[]"])
+		res.WriteHeader(http.StatusCreated)
+		res.Write([]byte(`{"id": 99, "url": "https://bot.example.com/hook", "events": ["message", "comment"], "active": true}`))
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+
+	params := url.Values{
+		"url":      {"https://bot.example.com/hook"},
+		"events[]": {"message", "comment"},
+	}
+	result, err := client.createWebhook(context.Background(), "org1", "flow1", params)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(99), result.ID)
+	assert.Equal(t, []string{"message", "comment"}, result.Events)
+}
+
+func Test_sendRequest_Should_Return_APIError_With_Status_Message_And_Request_Id(t *testing.T) {
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("X-Request-Id", "req-1")
+		res.WriteHeader(http.StatusTooManyRequests)
+		res.Write([]byte(`{"message":"Rate limit exceeded"}`))
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+	client.setRetryPolicy(0, 0, 0)
+
+	_, err := client.getFlow(context.Background(), "org", "flow")
+	apiError, ok := err.(*APIError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusTooManyRequests, apiError.StatusCode)
+	assert.Equal(t, "Rate limit exceeded", apiError.Message)
+	assert.Equal(t, "GET", apiError.Method)
+	assert.Equal(t, "/flows/org/flow", apiError.Endpoint)
+	assert.Equal(t, "req-1", apiError.RequestID)
+	assert.True(t, IsRateLimited(err))
+	assert.False(t, IsNotFound(err))
+}
+
+func Test_setBaseURL_Should_Point_Client_At_Custom_Api_Url(t *testing.T) {
+	client, _ := NewClient("apiKey")
+
+	assert.NoError(t, client.setBaseURL("http://localhost:8080/flowdock/"))
+	assert.Equal(t, "http://localhost:8080/flowdock", client.URL)
+
+	assert.Error(t, client.setBaseURL("localhost:8080"))
+	assert.Error(t, client.setBaseURL("ftp://localhost"))
+}
+
+func Test_configureHTTP_Should_Trust_Custom_CA_Cert_File(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte(flowMockBasic()))
+	}))
+	defer ts.Close()
+
+	caFile, _ := ioutil.TempFile("", "flowdock-ca")
+	defer os.Remove(caFile.Name())
+	pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	caFile.Close()
+
+	client, _ := NewClient("apiKey")
+	client.setRetryPolicy(0, 0, 0)
+	client.setBaseURL(ts.URL)
+
+	_, err := client.getFlow(context.Background(), "org", "ops-projects")
+	assert.Error(t, err, "the test server certificate isn't trusted by default")
+
+	err = client.configureHTTP(httpSettings{Timeout: time.Second, CACertFile: caFile.Name()})
+	assert.NoError(t, err)
+	_, err = client.getFlow(context.Background(), "org", "ops-projects")
+	assert.NoError(t, err)
+
+	err = client.configureHTTP(httpSettings{CACertFile: "/does/not/exist"})
+	assert.Error(t, err)
+}
+
+func Test_getFlow_Should_Return_As_Soon_As_Context_Is_Canceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	defer ts.Close()
+
+	client, _ := NewClient("MOCKED_API_KEY")
+	client.setBaseURL(ts.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := client.getFlow(ctx, "org", "ops-projects")
+
+	assert.True(t, errors.Is(err, context.Canceled), "got %v", err)
+	assert.True(t, time.Since(start) < defaultTimeout/2, "the request wasn't canceled")
+}
+
+func Test_waitForInvitationAccepted_Should_Poll_Until_Accepted(t *testing.T) {
+	polls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/flows/org/flow/invitations/1", req.URL.Path)
+		polls++
+		state := invitationPending
+		if polls == 3 {
+			state = invitationAccepted
+		}
+		fmt.Fprintf(res, `{"id":1,"email":"xxxxx@fairfaxmedia.co.nz","state":"%s"}`, state)
+	}))
+	defer ts.Close()
+
+	client, _ := NewClient("MOCKED_API_KEY")
+	client.setBaseURL(ts.URL)
+	invitation, err := client.waitForInvitationAccepted(context.Background(), "org", "flow", "1",
+		time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, err)
+	assert.Equal(t, invitationAccepted, invitation.State)
+	assert.Equal(t, 3, polls)
+}
+
+func Test_waitForInvitationAccepted_Should_Fail_On_Timeout_And_Revoked_Invitations(t *testing.T) {
+	cases := []struct {
+		state    string
+		timeout  time.Duration
+		expected string
+	}{
+		{invitationPending, 50 * time.Millisecond, "invitation 1 in org/flow was not accepted within 50ms"},
+		{"revoked", time.Second, "invitation 1 for xxxxx@fairfaxmedia.co.nz is revoked and can't be accepted anymore"},
+	}
+	for _, cc := range cases {
+		// a server per case, requests of the timed out wait may still be in flight
+		state := cc.state
+		ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(res, `{"id":1,"email":"xxxxx@fairfaxmedia.co.nz","state":"%s"}`, state)
+		}))
+
+		client, _ := NewClient("MOCKED_API_KEY")
+		client.setBaseURL(ts.URL)
+		_, err := client.waitForInvitationAccepted(context.Background(), "org", "flow", "1",
+			cc.timeout, 10*time.Millisecond)
+		assert.EqualError(t, err, cc.expected)
+		ts.Close()
+	}
+}
+
+func Test_waitForInvitationAccepted_Should_Stop_Before_Operation_Deadline(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(`{"id":1,"email":"xxxxx@fairfaxmedia.co.nz","state":"pending"}`))
+	}))
+	defer ts.Close()
+
+	client, _ := NewClient("MOCKED_API_KEY")
+	client.setBaseURL(ts.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := client.waitForInvitationAccepted(ctx, "org", "flow", "1", time.Hour, 10*time.Millisecond)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "was not accepted within")
+	assert.Contains(t, err.Error(), "the operation timeout is too short for acceptance_timeout")
+	assert.NoError(t, ctx.Err(), "there is time left to refresh the state")
+}
@@ -0,0 +1,67 @@
+package flowdock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// how long a credential helper may take to print the token
+const credentialHelperTimeout = 30 * time.Second
+
+// credentialHelperOutput is what a credential helper prints on stdout.
+type credentialHelperOutput struct {
+	APIToken string `json:"api_token"`
+}
+
+// readTokenFile reads the api token from path, e.g. a Vault agent sink.
+// Surrounding whitespace and the trailing newline are dropped.
+func readTokenFile(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("can't read api_token_file: %w", err)
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("api_token_file %s is empty", path)
+	}
+	registerSecret(token)
+	return token, nil
+}
+
+// runCredentialHelper runs command, split on whitespace into the executable
+// and its arguments, and reads the api token from the JSON it prints, e.g.
+// {"api_token": "xxxxxx"}. The helper is killed when ctx is canceled.
+func runCredentialHelper(ctx context.Context, command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("credential_helper is empty")
+	}
+	ctx, cancel := context.WithTimeout(ctx, credentialHelperTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("credential_helper %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	output := &credentialHelperOutput{}
+	if err := json.Unmarshal(stdout.Bytes(), output); err != nil {
+		// don't echo stdout, it most likely contains the token
+		return "", fmt.Errorf("credential_helper %s didn't print valid JSON: %w", args[0], err)
+	}
+	token := strings.TrimSpace(output.APIToken)
+	if token == "" {
+		return "", fmt.Errorf("credential_helper %s didn't return an api_token", args[0])
+	}
+	registerSecret(token)
+	return token, nil
+}
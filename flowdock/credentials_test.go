@@ -0,0 +1,86 @@
+package flowdock
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFile(t *testing.T, dir string, name string, content string, mode os.FileMode) string {
+	path := filepath.Join(dir, name)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), mode))
+	return path
+}
+
+func Test_readTokenFile_Should_Trim_Trailing_Newline(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "flowdock")
+	defer os.RemoveAll(dir)
+
+	path := writeTestFile(t, dir, "token", "file-token-1234\n", 0600)
+
+	token, err := readTokenFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "file-token-1234", token)
+}
+
+func Test_readTokenFile_Should_Fail_For_Empty_Or_Missing_File(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "flowdock")
+	defer os.RemoveAll(dir)
+
+	_, err := readTokenFile(writeTestFile(t, dir, "token", " \n", 0600))
+	assert.Error(t, err)
+
+	_, err = readTokenFile(filepath.Join(os.TempDir(), "flowdock-missing-token"))
+	assert.Error(t, err)
+}
+
+func Test_runCredentialHelper_Should_Return_Token_From_JSON(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "flowdock")
+	defer os.RemoveAll(dir)
+
+	helper := writeTestFile(t, dir, "helper.sh", "#!/bin/sh\necho '{\"api_token\": \"helper-token-'$1'\"}'\n", 0700)
+
+	token, err := runCredentialHelper(context.Background(), helper+" 1234")
+	assert.NoError(t, err)
+	assert.Equal(t, "helper-token-1234", token)
+}
+
+func Test_runCredentialHelper_Should_Fail_On_Error_Or_Missing_Token(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "flowdock")
+	defer os.RemoveAll(dir)
+
+	failing := writeTestFile(t, dir, "failing.sh", "#!/bin/sh\necho 'vault is sealed' >&2\nexit 1\n", 0700)
+	_, err := runCredentialHelper(context.Background(), failing)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "vault is sealed")
+
+	empty := writeTestFile(t, dir, "empty.sh", "#!/bin/sh\necho '{}'\n", 0700)
+	_, err = runCredentialHelper(context.Background(), empty)
+	assert.Error(t, err)
+
+	invalid := writeTestFile(t, dir, "invalid.sh", "#!/bin/sh\necho 'secret-value'\n", 0700)
+	_, err = runCredentialHelper(context.Background(), invalid)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-value")
+}
+
+func Test_runCredentialHelper_Should_Be_Killed_When_Context_Is_Canceled(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "flowdock")
+	defer os.RemoveAll(dir)
+
+	hanging := writeTestFile(t, dir, "hanging.sh", "#!/bin/sh\nexec sleep 30\n", 0700)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := runCredentialHelper(ctx, hanging)
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(ctx.Err(), context.Canceled))
+	assert.True(t, time.Since(start) < 5*time.Second, "the helper wasn't killed")
+}
@@ -0,0 +1,106 @@
+package flowdock
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func DataSourceFlows() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceFlowsRead,
+		Schema: map[string]*schema.Schema{
+			"org": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"flows": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"flow_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"org": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"parameterized_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"open": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"joined": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"access_mode": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceFlowsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org := d.Get("org").(string)
+	nameRegex := d.Get("name_regex").(string)
+
+	var re *regexp.Regexp
+	if nameRegex != "" {
+		re = regexp.MustCompile(nameRegex)
+	}
+
+	flows, err := apiClient.listFlows(ctx)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("dataSourceFlowsRead failed: %w", err))
+	}
+
+	result := make([]interface{}, 0, len(flows))
+	for _, flow := range flows {
+		if org != "" && flow.Organization.APIName != org {
+			continue
+		}
+		if re != nil && !re.MatchString(flow.Name) {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"flow_id":            flow.ID,
+			"org":                flow.Organization.APIName,
+			"name":               flow.Name,
+			"parameterized_name": flow.APIName,
+			"open":               flow.Open,
+			"joined":             flow.Joined,
+			"access_mode":        flow.AccessMode,
+		})
+	}
+
+	d.SetId(strconv.Itoa(schema.HashString(org + "/" + nameRegex)))
+	if err := d.Set("flows", result); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
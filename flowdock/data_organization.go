@@ -0,0 +1,114 @@
+package flowdock
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func DataSourceOrganization() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceOrganizationRead,
+		Schema: map[string]*schema.Schema{
+			"parameterized_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"organization_id": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"url": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"user_limit": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"user_count": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"users": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     dataSourceUserElem(),
+			},
+		},
+	}
+}
+
+// dataSourceUserElem describes a user as exported by the plural data sources
+func dataSourceUserElem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"email": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"nick": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"admin": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"disabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func flattenUsers(users []User) []interface{} {
+	result := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		result = append(result, map[string]interface{}{
+			"id":       strconv.FormatInt(user.ID, 10),
+			"email":    user.Email,
+			"name":     user.Name,
+			"nick":     user.Nick,
+			"admin":    user.Admin,
+			"disabled": user.Disabled,
+		})
+	}
+	return result
+}
+
+func dataSourceOrganizationRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org := d.Get("parameterized_name").(string)
+
+	organization, err := apiClient.getOrganization(ctx, org)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(organization.APIName)
+	d.Set("name", organization.Name)
+	d.Set("organization_id", organization.ID)
+	d.Set("url", organization.APIURL)
+	d.Set("user_limit", organization.UserLimit)
+	d.Set("user_count", organization.UserCount)
+	if err := d.Set("users", flattenUsers(organization.Users)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
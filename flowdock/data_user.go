@@ -1,18 +1,18 @@
 package flowdock
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"strconv"
+	"strings"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func DataSourceUser() *schema.Resource {
 	return &schema.Resource{
-		Read: dataSourceUserRead,
+		ReadContext: dataSourceUserRead,
 		Schema: map[string]*schema.Schema{
 			"email": &schema.Schema{
 				Type:     schema.TypeString,
@@ -30,33 +30,18 @@ func DataSourceUser() *schema.Resource {
 	}
 }
 
-func dataSourceUserRead(d *schema.ResourceData, meta interface{}) error {
+func dataSourceUserRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	apiClient := meta.(*Client)
 	org := d.Get("org").(string)
 	email := d.Get("email").(string)
-	var url string
 
-	if org != "" {
-		url = fmt.Sprintf("https://%s@api.flowdock.com/organizations/%s/users", apiClient.ApiKey, org)
-	} else {
-		url = fmt.Sprintf("https://%s@api.flowdock.com/users", apiClient.ApiKey)
-	}
-	log.Printf("[DEBUG] Reading url: %s", url)
-	res, err := apiClient.Http.Get(url)
-	if err != nil {
-		log.Printf("dataSourcesUserRead error:%s", err.Error())
-	}
-	defer res.Body.Close()
-
-	var users []User
-	body, err := ioutil.ReadAll(res.Body)
+	users, err := apiClient.listOrgUsers(ctx, org)
 	if err != nil {
-		panic(err.Error())
+		return diag.FromErr(fmt.Errorf("dataSourceUserRead failed: %w", err))
 	}
-	json.Unmarshal(body, &users)
 
 	for _, user := range users {
-		if user.Email == email {
+		if strings.EqualFold(user.Email, email) {
 			d.SetId(strconv.FormatInt(user.ID, 10))
 			_ = d.Set("name", user.Name)
 			_ = d.Set("email", user.Email)
@@ -64,5 +49,5 @@ func dataSourceUserRead(d *schema.ResourceData, meta interface{}) error {
 			return nil
 		}
 	}
-	return nil
+	return attributeError("email", fmt.Errorf("no user with email %s in org %s", email, org))
 }
@@ -0,0 +1,31 @@
+package flowdock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_dataSourceUserRead_Should_Ignore_Case_Of_Email(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/organizations/org/users", req.URL.Path)
+		res.Write([]byte(`[{"id": 123456, "email": "Xxxxx@FairfaxMedia.co.nz", "name": "Xavier"}]`))
+	}))
+	defer ts.Close()
+	client, _ := NewClient("apiKey")
+	client.setBaseURL(ts.URL)
+
+	d := schema.TestResourceDataRaw(t, DataSourceUser().Schema, map[string]interface{}{
+		"org":   "org",
+		"email": "xxxxx@fairfaxmedia.co.nz",
+	})
+	diags := dataSourceUserRead(context.Background(), d, client)
+
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, "123456", d.Id())
+	assert.Equal(t, "Xavier", d.Get("name"))
+}
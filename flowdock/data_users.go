@@ -0,0 +1,108 @@
+package flowdock
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func DataSourceUsers() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceUsersRead,
+		Schema: map[string]*schema.Schema{
+			"org": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"email_domain": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"admin_only": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"disabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"users": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     dataSourceUserElem(),
+			},
+		},
+	}
+}
+
+// userFilter holds the optional filters of the flowdock_users data source
+type userFilter struct {
+	emailDomain string
+	adminOnly   bool
+	nameRegex   *regexp.Regexp
+	// nil keeps both enabled and disabled users
+	disabled *bool
+}
+
+func (filter userFilter) match(user User) bool {
+	if filter.emailDomain != "" &&
+		!strings.HasSuffix(strings.ToLower(user.Email), "@"+strings.ToLower(filter.emailDomain)) {
+		return false
+	}
+	if filter.adminOnly && !user.Admin {
+		return false
+	}
+	if filter.nameRegex != nil && !filter.nameRegex.MatchString(user.Name) {
+		return false
+	}
+	if filter.disabled != nil && *filter.disabled != user.Disabled {
+		return false
+	}
+	return true
+}
+
+func dataSourceUsersRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org := d.Get("org").(string)
+
+	filter := userFilter{
+		emailDomain: strings.TrimPrefix(d.Get("email_domain").(string), "@"),
+		adminOnly:   d.Get("admin_only").(bool),
+	}
+	if v, ok := d.GetOk("name_regex"); ok {
+		filter.nameRegex = regexp.MustCompile(v.(string))
+	}
+	if v, ok := d.GetOkExists("disabled"); ok {
+		disabled := v.(bool)
+		filter.disabled = &disabled
+	}
+
+	users, err := apiClient.listOrgUsers(ctx, org)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("dataSourceUsersRead failed: %w", err))
+	}
+
+	var matched []User
+	for _, user := range users {
+		if filter.match(user) {
+			matched = append(matched, user)
+		}
+	}
+
+	d.SetId(org)
+	if err := d.Set("users", flattenUsers(matched)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package flowdock
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userFilter_Should_Match_Users_On_Every_Given_Filter(t *testing.T) {
+	disabled := true
+	admin := User{ID: 1, Email: "xxxxx@Fairfaxmedia.co.nz", Name: "Xavier", Admin: true}
+	member := User{ID: 2, Email: "yyyyy@gmail.com", Name: "Yvonne"}
+	gone := User{ID: 3, Email: "zzzzz@fairfaxmedia.co.nz", Name: "Zed", Disabled: true}
+
+	cases := []struct {
+		name     string
+		filter   userFilter
+		expected []bool
+	}{
+		{name: "no filter", filter: userFilter{}, expected: []bool{true, true, true}},
+		{name: "email domain", filter: userFilter{emailDomain: "fairfaxmedia.co.nz"}, expected: []bool{true, false, true}},
+		{name: "admin only", filter: userFilter{adminOnly: true}, expected: []bool{true, false, false}},
+		{name: "name regex", filter: userFilter{nameRegex: regexp.MustCompile("^Y")}, expected: []bool{false, true, false}},
+		{name: "disabled", filter: userFilter{disabled: &disabled}, expected: []bool{false, false, true}},
+	}
+
+	for _, cc := range cases {
+		t.Run(cc.name, func(t *testing.T) {
+			assert.Equal(t, cc.expected, []bool{
+				cc.filter.match(admin), cc.filter.match(member), cc.filter.match(gone),
+			})
+		})
+	}
+}
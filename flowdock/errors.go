@@ -0,0 +1,69 @@
+package flowdock
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// An APIError is returned by every Client call that got a non-2xx response
+// from Flowdock.
+type APIError struct {
+	StatusCode int
+	// Message returned by Flowdock in the response body, if any.
+	Message string
+	Method  string
+	// Endpoint is the request path, it never contains the API token.
+	Endpoint  string
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("flowdock API error: %s %s returned %d", e.Method, e.Endpoint, e.StatusCode)
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+	if e.RequestID != "" {
+		msg = fmt.Sprintf("%s (request id %s)", msg, e.RequestID)
+	}
+	return msg
+}
+
+func hasStatus(err error, statusCode int) bool {
+	var apiError *APIError
+	return errors.As(err, &apiError) && apiError.StatusCode == statusCode
+}
+
+// IsNotFound reports whether err means the resource doesn't exist anymore and
+// should be removed from state.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsForbidden reports whether the token isn't allowed to access the resource.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsUnauthorized reports whether the token was rejected.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsRateLimited reports whether Flowdock throttled the request.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// attributeError reports err against the given top level attribute, so that
+// terraform points at the offending line of the configuration.
+func attributeError(attribute string, err error) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity:      diag.Error,
+		Summary:       err.Error(),
+		AttributePath: cty.GetAttrPath(attribute),
+	}}
+}
@@ -0,0 +1,126 @@
+package flowdock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// access tokens are refreshed this long before they expire
+const tokenExpiryMargin = 30 * time.Second
+
+// oauthCredentials authenticate a Client as an OAuth application. The access
+// token is fetched with the refresh token on first use and refreshed again
+// when it expires or gets rejected.
+type oauthCredentials struct {
+	clientID     string
+	clientSecret string
+
+	mu           sync.Mutex
+	refreshToken string
+	accessToken  string
+	expiry       time.Time
+}
+
+// tokenResponse, as returned by POST /oauth/token
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Error        string `json:"error"`
+	ErrorMessage string `json:"error_description"`
+}
+
+// NewOAuthClient creates a new Client that authenticates as an OAuth
+// application instead of with a personal API token.
+func NewOAuthClient(clientID string, clientSecret string, refreshToken string) (*Client, error) {
+	if len(strings.TrimSpace(clientID)) == 0 || len(strings.TrimSpace(clientSecret)) == 0 ||
+		len(strings.TrimSpace(refreshToken)) == 0 {
+		return nil, fmt.Errorf("oauth_client_id, oauth_client_secret and oauth_refresh_token are all required")
+	}
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	client.oauth = &oauthCredentials{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		refreshToken: refreshToken,
+	}
+	registerSecret(clientSecret)
+	registerSecret(refreshToken)
+	return client, nil
+}
+
+// accessTokenFor returns a valid access token, refreshing it through client
+// when needed.
+func (creds *oauthCredentials) accessTokenFor(ctx context.Context, client *Client) (string, error) {
+	creds.mu.Lock()
+	defer creds.mu.Unlock()
+
+	if creds.accessToken != "" && (creds.expiry.IsZero() || time.Now().Before(creds.expiry.Add(-tokenExpiryMargin))) {
+		return creds.accessToken, nil
+	}
+
+	params := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {creds.refreshToken},
+		"client_id":     {creds.clientID},
+		"client_secret": {creds.clientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", client.URL+"/oauth/token", strings.NewReader(params.Encode()))
+	if err != nil {
+		return "", &redactedError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := client.Http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("refreshing the OAuth access token failed: %w", &redactedError{err})
+	}
+	defer res.Body.Close()
+
+	token := &tokenResponse{}
+	decodeErr := json.NewDecoder(res.Body).Decode(token)
+	if res.StatusCode < 200 || res.StatusCode > 299 || decodeErr != nil || token.AccessToken == "" {
+		return "", &APIError{
+			StatusCode: res.StatusCode,
+			Message:    strings.TrimSpace(token.Error + " " + token.ErrorMessage),
+			Method:     "POST",
+			Endpoint:   req.URL.Path,
+			RequestID:  res.Header.Get("X-Request-Id"),
+		}
+	}
+
+	registerSecret(token.AccessToken)
+	// plan and apply run in separate processes that all start from the
+	// configured refresh token, a rotated one would be lost when this one exits
+	if token.RefreshToken != "" && token.RefreshToken != creds.refreshToken {
+		registerSecret(token.RefreshToken)
+		return "", fmt.Errorf("Flowdock rotated the OAuth refresh token, the configured oauth_refresh_token " +
+			"may no longer be valid: authorize an OAuth application whose refresh tokens don't rotate, " +
+			"or use api_token instead")
+	}
+	creds.accessToken = token.AccessToken
+	creds.expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		creds.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return creds.accessToken, nil
+}
+
+// expire forgets an access token the server rejected, so the next request
+// refreshes it.
+func (creds *oauthCredentials) expire(accessToken string) {
+	creds.mu.Lock()
+	defer creds.mu.Unlock()
+	if creds.accessToken == accessToken {
+		creds.accessToken = ""
+	}
+}
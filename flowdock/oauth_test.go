@@ -0,0 +1,95 @@
+package flowdock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewOAuthClient_Should_Require_All_Credentials(t *testing.T) {
+	_, err := NewOAuthClient("client-id", "", "refresh-token")
+	assert.Error(t, err)
+}
+
+func Test_OAuthClient_Should_Refresh_Access_Token_And_Send_It_As_Bearer(t *testing.T) {
+	refreshes := 0
+	rejectNext := false
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/oauth/token" {
+			refreshes++
+			req.ParseForm()
+			assert.Equal(t, "refresh_token", req.PostForm.Get("grant_type"))
+			assert.Equal(t, "client-id", req.PostForm.Get("client_id"))
+			assert.Equal(t, "client-secret", req.PostForm.Get("client_secret"))
+			assert.Equal(t, "refresh-token-1", req.PostForm.Get("refresh_token"))
+			if refreshes == 1 {
+				res.Write([]byte(`{"access_token": "access-token-1", "token_type": "bearer", "expires_in": 7200, "refresh_token": "refresh-token-1"}`))
+			} else {
+				res.Write([]byte(`{"access_token": "access-token-2", "token_type": "bearer", "expires_in": 7200}`))
+			}
+			return
+		}
+		expected := "Bearer access-token-1"
+		if refreshes > 1 {
+			expected = "Bearer access-token-2"
+		}
+		if rejectNext {
+			rejectNext = false
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, expected, req.Header.Get("Authorization"))
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte(flowMockBasic()))
+	}))
+	defer ts.Close()
+
+	client, err := NewOAuthClient("client-id", "client-secret", "refresh-token-1")
+	assert.NoError(t, err)
+	client.setBaseURL(ts.URL)
+
+	_, err = client.getFlow(context.Background(), "org", "ops-projects")
+	assert.NoError(t, err)
+	_, err = client.getFlow(context.Background(), "org", "ops-projects")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, refreshes, "the access token is reused until it expires")
+
+	rejectNext = true
+	_, err = client.getFlow(context.Background(), "org", "ops-projects")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, refreshes, "a rejected access token is refreshed once")
+}
+
+func Test_OAuthClient_Should_Fail_When_Refresh_Is_Rejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusBadRequest)
+		res.Write([]byte(`{"error": "invalid_grant", "error_description": "refresh token revoked"}`))
+	}))
+	defer ts.Close()
+
+	client, _ := NewOAuthClient("client-id", "client-secret", "refresh-token")
+	client.setBaseURL(ts.URL)
+
+	_, err := client.getFlow(context.Background(), "org", "ops-projects")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid_grant refresh token revoked")
+}
+
+func Test_OAuthClient_Should_Fail_When_Refresh_Token_Is_Rotated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/oauth/token", req.URL.Path)
+		res.Write([]byte(`{"access_token": "access-token-1", "expires_in": 7200, "refresh_token": "refresh-token-2"}`))
+	}))
+	defer ts.Close()
+
+	client, _ := NewOAuthClient("client-id", "client-secret", "refresh-token-1")
+	client.setBaseURL(ts.URL)
+
+	_, err := client.getFlow(context.Background(), "org", "ops-projects")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rotated the OAuth refresh token")
+	assert.NotContains(t, err.Error(), "refresh-token-2")
+}
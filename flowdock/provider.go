@@ -1,35 +1,248 @@
 package flowdock
 
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
-func Provider() terraform.ResourceProvider {
+func Provider() *schema.Provider {
 	return &schema.Provider{
 
 		Schema: map[string]*schema.Schema{
 			"api_token": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Sensitive:   true,
 				DefaultFunc: schema.EnvDefaultFunc("FLOWDOCK_TOKEN", nil),
-				Description: "please add your api token from https://www.flowdock.com/account/tokens",
+				Description: "please add your api token from https://www.flowdock.com/account/tokens, not needed with OAuth credentials",
+			},
+			"api_token_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("FLOWDOCK_TOKEN_FILE", nil),
+				ConflictsWith: []string{"api_token", "credential_helper"},
+				Description:   "file the api token is read from, e.g. a Vault agent sink",
+			},
+			"credential_helper": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("FLOWDOCK_CREDENTIAL_HELPER", nil),
+				ConflictsWith: []string{"api_token"},
+				Description:   `command printing the api token as JSON, e.g. {"api_token": "xxxxxx"}`,
+			},
+			"oauth_client_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("FLOWDOCK_OAUTH_CLIENT_ID", nil),
+				ConflictsWith: []string{"api_token", "api_token_file", "credential_helper"},
+				Description:   "client id of the OAuth application to authenticate as instead of api_token",
+			},
+			"oauth_client_secret": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				DefaultFunc:   schema.EnvDefaultFunc("FLOWDOCK_OAUTH_CLIENT_SECRET", nil),
+				ConflictsWith: []string{"api_token", "api_token_file", "credential_helper"},
+				Description:   "client secret of the OAuth application",
+			},
+			"oauth_refresh_token": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				DefaultFunc:   schema.EnvDefaultFunc("FLOWDOCK_OAUTH_REFRESH_TOKEN", nil),
+				ConflictsWith: []string{"api_token", "api_token_file", "credential_helper"},
+				Description:   "refresh token the OAuth application was authorized with",
+			},
+			"api_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("FLOWDOCK_API_URL", defaultAPIURL),
+				Description: "base url of the Flowdock API, e.g. a recording proxy or a local stub",
+			},
+			"timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(defaultTimeout / time.Second),
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "seconds a single request may take, every retry gets a fresh timeout",
+			},
+			"proxy_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "proxy requests are sent through, defaults to the HTTPS_PROXY environment variable",
+			},
+			"ca_cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "PEM file with extra CA certificates to trust, e.g. the one of an intercepting proxy",
+			},
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "don't verify the TLS certificate of the API, only meant for local testing",
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultMaxRetries,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "how many times a request failing with a 5xx, 429 or network error is retried",
+			},
+			"retry_wait_min": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(defaultMinWait / time.Second),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "seconds to wait before the first retry, doubled on every attempt",
+			},
+			"retry_wait_max": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(defaultMaxWait / time.Second),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "maximum seconds to wait between retries unless the server sends Retry-After",
+			},
+			"requests_per_second": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				Default:      defaultRequestsPerSecond,
+				ValidateFunc: validation.FloatAtLeast(0),
+				Description:  "maximum requests per second sent to Flowdock by all resources together, 0 disables the limit",
+			},
+			"burst": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultBurst,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "how many requests may be sent at once before requests_per_second applies",
+			},
+			"user_cache_ttl": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(defaultUserCacheTTL / time.Second),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "seconds organization user lists are cached for lookups by email or ID, 0 disables the cache",
 			},
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"flowdock_user": DataSourceUser(),
+			"flowdock_flows":        DataSourceFlows(),
+			"flowdock_organization": DataSourceOrganization(),
+			"flowdock_user":         DataSourceUser(),
+			"flowdock_users":        DataSourceUsers(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"flowdock_invitation":   ResourceInvitation(),
-			"flowdock_organization": ResourceOrganization(),
-			"flowdock_user":         ResourceUser(),
+			"flowdock_flow":              ResourceFlow(),
+			"flowdock_flow_members":      ResourceFlowMembers(),
+			"flowdock_flow_membership":   ResourceFlowMembership(),
+			"flowdock_invitation":        ResourceInvitation(),
+			"flowdock_organization":      ResourceOrganization(),
+			"flowdock_organization_user": ResourceOrganizationUser(),
+			"flowdock_source":            ResourceSource(),
+			"flowdock_user":              ResourceUser(),
+			"flowdock_webhook":           ResourceWebhook(),
 		},
-		ConfigureFunc: providerConfigure,
+		ConfigureContextFunc: providerConfigure,
+	}
+}
+
+func providerConfigure(ctx context.Context, provider *schema.ResourceData) (interface{}, diag.Diagnostics) {
+	client, diags := newProviderClient(ctx, provider)
+	if diags.HasError() {
+		return nil, diags
+	}
+	if err := client.setBaseURL(provider.Get("api_url").(string)); err != nil {
+		return nil, attributeError("api_url", err)
+	}
+	err := client.configureHTTP(httpSettings{
+		Timeout:            time.Duration(provider.Get("timeout").(int)) * time.Second,
+		ProxyURL:           provider.Get("proxy_url").(string),
+		CACertFile:         provider.Get("ca_cert_file").(string),
+		InsecureSkipVerify: provider.Get("insecure_skip_verify").(bool),
+	})
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	client.setRetryPolicy(provider.Get("max_retries").(int),
+		time.Duration(provider.Get("retry_wait_min").(int))*time.Second,
+		time.Duration(provider.Get("retry_wait_max").(int))*time.Second)
+	client.setRateLimit(provider.Get("requests_per_second").(float64), provider.Get("burst").(int))
+	client.setUserCacheTTL(time.Duration(provider.Get("user_cache_ttl").(int)) * time.Second)
+	registerClient(client)
+	return client, nil
+}
+
+// newProviderClient authenticates with the OAuth application when any of its
+// credentials is given and with api_token otherwise, which is read from
+// api_token_file or credential_helper when it isn't set directly.
+func newProviderClient(ctx context.Context, provider *schema.ResourceData) (*Client, diag.Diagnostics) {
+	clientID := provider.Get("oauth_client_id").(string)
+	clientSecret := provider.Get("oauth_client_secret").(string)
+	refreshToken := provider.Get("oauth_refresh_token").(string)
+	if clientID != "" || clientSecret != "" || refreshToken != "" {
+		// environment variables aren't checked by ConflictsWith
+		for _, attribute := range []string{"api_token", "api_token_file", "credential_helper"} {
+			if provider.Get(attribute).(string) != "" {
+				return nil, attributeError(attribute, fmt.Errorf("%s can't be used together with the oauth_* credentials, "+
+					"check the FLOWDOCK_* environment variables", attribute))
+			}
+		}
+		client, err := NewOAuthClient(clientID, clientSecret, refreshToken)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		return client, nil
+	}
+	token, diags := providerToken(ctx, provider)
+	if diags.HasError() {
+		return nil, diags
+	}
+	client, err := NewClient(token)
+	if err != nil {
+		return nil, attributeError("api_token", err)
 	}
+	return client, nil
 }
 
-func providerConfigure(provider *schema.ResourceData) (interface{}, error) {
-	return NewClient(provider.Get("api_token").(string))
+func providerToken(ctx context.Context, provider *schema.ResourceData) (string, diag.Diagnostics) {
+	// environment variables aren't checked by ConflictsWith, a leftover
+	// FLOWDOCK_TOKEN must not win over the configured token source
+	var sources []string
+	for _, attribute := range []string{"api_token", "api_token_file", "credential_helper"} {
+		if provider.Get(attribute).(string) != "" {
+			sources = append(sources, attribute)
+		}
+	}
+	if len(sources) > 1 {
+		return "", attributeError(sources[1], fmt.Errorf("only one of %s can be set, "+
+			"check the FLOWDOCK_* environment variables", strings.Join(sources, ", ")))
+	}
+
+	if token := provider.Get("api_token").(string); token != "" {
+		return token, nil
+	}
+	if path := provider.Get("api_token_file").(string); path != "" {
+		token, err := readTokenFile(path)
+		if err != nil {
+			return "", attributeError("api_token_file", err)
+		}
+		return token, nil
+	}
+	if command := provider.Get("credential_helper").(string); command != "" {
+		token, err := runCredentialHelper(ctx, command)
+		if err != nil {
+			return "", attributeError("credential_helper", err)
+		}
+		return token, nil
+	}
+	return "", diag.Errorf("one of api_token, api_token_file, credential_helper or " +
+		"oauth_client_id, oauth_client_secret and oauth_refresh_token must be set")
 }
@@ -1,20 +1,24 @@
 package flowdock
 
 import (
+	"context"
 	"os"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
 )
 
-var testAccProviders map[string]terraform.ResourceProvider
+var testAccProviders map[string]func() (*schema.Provider, error)
 var testAccProvider *schema.Provider
 
 func init() {
-	testAccProvider = Provider().(*schema.Provider)
-	testAccProviders = map[string]terraform.ResourceProvider{
-		"flowdock": testAccProvider,
+	testAccProvider = Provider()
+	// every step shares testAccProvider, so that checks can use its client
+	testAccProviders = map[string]func() (*schema.Provider, error){
+		"flowdock": func() (*schema.Provider, error) { return testAccProvider, nil },
 	}
 }
 
@@ -25,11 +29,61 @@ func testAccPreCheck(t *testing.T) {
 	}
 }
 func TestAccProvider(t *testing.T) {
-	if err := Provider().(*schema.Provider).InternalValidate(); err != nil {
+	if err := Provider().InternalValidate(); err != nil {
 		t.Fatalf("error: %s", err)
 	}
 }
 
 func TestAccProvider_impl(t *testing.T) {
-	var _ terraform.ResourceProvider = Provider()
+	var _ *schema.Provider = Provider()
+}
+
+func Test_Provider_Should_Report_Invalid_Api_Url_Against_The_Attribute(t *testing.T) {
+	diags := Provider().Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"api_token": "MOCKED_API_KEY",
+		"api_url":   "ftp://api.flowdock.com",
+	}))
+
+	assert.True(t, diags.HasError())
+	assert.Equal(t, cty.GetAttrPath("api_url"), diags[0].AttributePath)
+}
+
+func Test_Provider_Should_Reject_Token_From_Environment_Together_With_OAuth(t *testing.T) {
+	previous, wasSet := os.LookupEnv("FLOWDOCK_TOKEN")
+	os.Setenv("FLOWDOCK_TOKEN", "MOCKED_API_KEY")
+	defer func() {
+		if wasSet {
+			os.Setenv("FLOWDOCK_TOKEN", previous)
+		} else {
+			os.Unsetenv("FLOWDOCK_TOKEN")
+		}
+	}()
+
+	diags := Provider().Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"oauth_client_id":     "client-id",
+		"oauth_client_secret": "client-secret",
+		"oauth_refresh_token": "refresh-token",
+	}))
+
+	assert.True(t, diags.HasError())
+	assert.Equal(t, cty.GetAttrPath("api_token"), diags[0].AttributePath)
+}
+
+func Test_Provider_Should_Reject_Token_From_Environment_Together_With_Token_File(t *testing.T) {
+	previous, wasSet := os.LookupEnv("FLOWDOCK_TOKEN")
+	os.Setenv("FLOWDOCK_TOKEN", "tok-from-env")
+	defer func() {
+		if wasSet {
+			os.Setenv("FLOWDOCK_TOKEN", previous)
+		} else {
+			os.Unsetenv("FLOWDOCK_TOKEN")
+		}
+	}()
+
+	diags := Provider().Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"api_token_file": "/run/secrets/flowdock-token",
+	}))
+
+	assert.True(t, diags.HasError())
+	assert.Equal(t, cty.GetAttrPath("api_token_file"), diags[0].AttributePath)
 }
@@ -0,0 +1,154 @@
+package flowdock
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRequestsPerSecond = 5.0
+	defaultBurst             = 10
+)
+
+// rateLimiter is a token bucket shared by every request of a Client, so the
+// operations terraform runs in parallel don't exceed the Flowdock rate limit
+// together.
+type rateLimiter struct {
+	mu sync.Mutex
+	// tokens added per second, 0 disables the limiter
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	requests    int64
+	delayed     int64
+	rateLimited int64
+	totalWait   time.Duration
+	maxWait     time.Duration
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	limiter := &rateLimiter{}
+	limiter.configure(rate, burst)
+	return limiter
+}
+
+func (limiter *rateLimiter) configure(rate float64, burst int) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if burst < 1 {
+		burst = 1
+	}
+	limiter.rate = rate
+	limiter.burst = float64(burst)
+	limiter.tokens = float64(burst)
+	limiter.last = time.Now()
+}
+
+// reserve takes a token and returns how long the caller has to wait before
+// using it. Tokens may go negative so that concurrent callers queue up
+// instead of all waking up at the same time.
+func (limiter *rateLimiter) reserve() time.Duration {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.requests++
+	if limiter.rate <= 0 {
+		return 0
+	}
+	now := time.Now()
+	limiter.tokens += now.Sub(limiter.last).Seconds() * limiter.rate
+	if limiter.tokens > limiter.burst {
+		limiter.tokens = limiter.burst
+	}
+	limiter.last = now
+
+	limiter.tokens--
+	if limiter.tokens >= 0 {
+		return 0
+	}
+	wait := time.Duration(-limiter.tokens / limiter.rate * float64(time.Second))
+	limiter.delayed++
+	limiter.totalWait += wait
+	if wait > limiter.maxWait {
+		limiter.maxWait = wait
+	}
+	return wait
+}
+
+// cancel gives back a token reserved by a caller that gave up waiting.
+func (limiter *rateLimiter) cancel() {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.tokens++
+}
+
+func (limiter *rateLimiter) wait(ctx context.Context) error {
+	wait := limiter.reserve()
+	if wait == 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		limiter.cancel()
+		return ctx.Err()
+	}
+}
+
+func (limiter *rateLimiter) logStats() {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	log.Printf("[INFO] flowdock client: %d requests, %d delayed by the rate limiter "+
+		"(total wait %s, longest %s), %d rate limited by the server",
+		limiter.requests, limiter.delayed, limiter.totalWait.Round(time.Millisecond),
+		limiter.maxWait.Round(time.Millisecond), limiter.rateLimited)
+}
+
+// rateLimitTransport makes every attempt, including retries, take a token
+// from the limiter before it is sent.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	res, err := t.base.RoundTrip(req)
+	if res != nil && res.StatusCode == http.StatusTooManyRequests {
+		t.limiter.mu.Lock()
+		t.limiter.rateLimited++
+		t.limiter.mu.Unlock()
+	}
+	return res, err
+}
+
+var (
+	configuredClientsMu sync.Mutex
+	configuredClients   []*Client
+)
+
+func registerClient(client *Client) {
+	configuredClientsMu.Lock()
+	defer configuredClientsMu.Unlock()
+	configuredClients = append(configuredClients, client)
+}
+
+// LogClientStats logs the request metrics of every client the provider has
+// configured, it is meant to be called once the plugin stops serving.
+func LogClientStats() {
+	configuredClientsMu.Lock()
+	defer configuredClientsMu.Unlock()
+	for _, client := range configuredClients {
+		client.limiter.logStats()
+	}
+}
@@ -0,0 +1,67 @@
+package flowdock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rateLimiter_Should_Allow_Burst_Then_Queue_Requests(t *testing.T) {
+	limiter := newRateLimiter(10, 2)
+
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	third := limiter.reserve()
+	fourth := limiter.reserve()
+	assert.True(t, third > 80*time.Millisecond && third <= 100*time.Millisecond)
+	assert.True(t, fourth > 180*time.Millisecond && fourth <= 200*time.Millisecond)
+	assert.Equal(t, int64(4), limiter.requests)
+	assert.Equal(t, int64(2), limiter.delayed)
+}
+
+func Test_rateLimiter_Should_Not_Wait_When_Disabled(t *testing.T) {
+	limiter := newRateLimiter(0, 1)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, time.Duration(0), limiter.reserve())
+	}
+}
+
+func Test_rateLimiter_Should_Stop_Waiting_When_Context_Is_Cancelled(t *testing.T) {
+	limiter := newRateLimiter(0.01, 1)
+	limiter.reserve()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, limiter.wait(ctx))
+}
+
+func Test_Client_Should_Share_Rate_Limit_Across_Parallel_Requests(t *testing.T) {
+	client, _ := NewClient("apiKey")
+	client.setRateLimit(50, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte(flowMockBasic()))
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.getFlow(context.Background(), "org", "ops-projects")
+		}()
+	}
+	wg.Wait()
+
+	// one request goes out right away, the other four are spaced by 20ms
+	assert.True(t, time.Since(start) >= 75*time.Millisecond)
+	assert.Equal(t, int64(5), client.limiter.requests)
+}
@@ -0,0 +1,87 @@
+package flowdock
+
+import (
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// credentials written in url userinfo, e.g. https://token@api.flowdock.com
+var userinfoPattern = regexp.MustCompile(`(://)[^/@\s]+@`)
+
+// redactor scrubs the secrets it knows about from log lines and error
+// messages.
+type redactor struct {
+	mu      sync.RWMutex
+	secrets []string
+}
+
+// logRedactor is shared by every client, the standard logger it protects is
+// global as well.
+var (
+	logRedactor    = &redactor{}
+	installLogOnce sync.Once
+)
+
+func (r *redactor) add(secret string) {
+	// short values would scrub unrelated text
+	if len(strings.TrimSpace(secret)) < 4 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, known := range r.secrets {
+		if known == secret {
+			return
+		}
+	}
+	r.secrets = append(r.secrets, secret)
+}
+
+func (r *redactor) redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, secret := range r.secrets {
+		s = strings.Replace(s, secret, redacted, -1)
+	}
+	return userinfoPattern.ReplaceAllString(s, "${1}"+redacted+"@")
+}
+
+// redactingWriter scrubs secrets from everything written through it.
+type redactingWriter struct {
+	out io.Writer
+	r   *redactor
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.out, w.r.redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// registerSecret makes sure secret never shows up in the TF_LOG output.
+func registerSecret(secret string) {
+	logRedactor.add(secret)
+	installLogOnce.Do(func() {
+		log.SetOutput(&redactingWriter{out: log.Writer(), r: logRedactor})
+	})
+}
+
+// redactedError hides secrets in the message of the wrapped error while
+// keeping it available to errors.As.
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return logRedactor.redact(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,181 @@
+package flowdock
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// flows resource, as seen by GET /flows/:org/:flow
+type Flow struct {
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	APIName      string       `json:"parameterized_name"`
+	Description  string       `json:"description"`
+	AccessMode   string       `json:"access_mode"`
+	Open         bool         `json:"open"`
+	Joined       bool         `json:"joined"`
+	Disabled     bool         `json:"disabled"`
+	APIURL       string       `json:"url"`
+	WebURL       string       `json:"web_url"`
+	Organization Organization `json:"organization"`
+	MESSAGE      string       `json:"message"`
+}
+
+func ResourceFlow() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: flowCreate,
+		ReadContext:   flowRead,
+		UpdateContext: flowUpdate,
+		DeleteContext: flowDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"org": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"access_mode": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					"invitation", "link", "organization",
+				}, false),
+			},
+			"parameterized_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"flow_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"web_url": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// flow ids are stored as org/parameterized_name
+func parseFlowId(id string) (string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unexpected flow id %q, expected org/flow", id)
+	}
+	return parts[0], parts[1], nil
+}
+
+func flowCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org := d.Get("org").(string)
+	name := d.Get("name").(string)
+
+	flow, err := apiClient.createFlow(ctx, org, name)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(fmt.Sprintf("%s/%s", org, flow.APIName))
+
+	params := url.Values{}
+	if v, ok := d.GetOk("description"); ok {
+		params.Set("description", v.(string))
+	}
+	if v, ok := d.GetOk("access_mode"); ok {
+		params.Set("access_mode", v.(string))
+	}
+	if len(params) > 0 {
+		if _, err := apiClient.updateFlow(ctx, org, flow.APIName, params); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return flowRead(ctx, d, meta)
+}
+
+func flowRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, name, err := parseFlowId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	flow, err := apiClient.getFlow(ctx, org, name)
+	if IsNotFound(err) || (err == nil && flow.Disabled) {
+		log.Printf("[WARN] flow %s is gone or archived, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("org", org)
+	d.Set("name", flow.Name)
+	d.Set("description", flow.Description)
+	d.Set("access_mode", flow.AccessMode)
+	d.Set("parameterized_name", flow.APIName)
+	d.Set("flow_id", flow.ID)
+	d.Set("web_url", flow.WebURL)
+	return nil
+}
+
+func flowUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, name, err := parseFlowId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	params := url.Values{}
+	if d.HasChange("name") {
+		params.Set("name", d.Get("name").(string))
+	}
+	if d.HasChange("description") {
+		params.Set("description", d.Get("description").(string))
+	}
+	if d.HasChange("access_mode") {
+		params.Set("access_mode", d.Get("access_mode").(string))
+	}
+	if len(params) > 0 {
+		flow, err := apiClient.updateFlow(ctx, org, name, params)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		// renaming a flow may change its parameterized name
+		if len(flow.APIName) > 0 {
+			d.SetId(fmt.Sprintf("%s/%s", org, flow.APIName))
+		}
+	}
+	return flowRead(ctx, d, meta)
+}
+
+func flowDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, name, err := parseFlowId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = apiClient.archiveFlow(ctx, org, name)
+	if err != nil && !IsNotFound(err) {
+		return diag.FromErr(err)
+	}
+	return nil
+}
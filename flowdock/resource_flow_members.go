@@ -0,0 +1,223 @@
+package flowdock
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// ResourceFlowMembers manages the complete member list of a flow, anyone who
+// isn't listed in members is removed from the flow.
+func ResourceFlowMembers() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: flowMembersCreate,
+		ReadContext:   flowMembersRead,
+		UpdateContext: flowMembersUpdate,
+		DeleteContext: flowMembersDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"org": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"flow": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			// user ids or emails
+			"members": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+		},
+	}
+}
+
+func isEmailMember(member string) bool {
+	return strings.Contains(member, "@")
+}
+
+// resolveFlowMembers turns a list of user ids and emails into user ids, emails
+// are looked up in the organization.
+func resolveFlowMembers(ctx context.Context, apiClient *Client, org string, members []string) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	var emails []string
+	for _, member := range members {
+		if isEmailMember(member) {
+			emails = append(emails, member)
+		} else {
+			ids[member] = true
+		}
+	}
+	if len(emails) == 0 {
+		return ids, nil
+	}
+
+	users, err := apiClient.listOrgUsers(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	for _, email := range emails {
+		found := false
+		for _, user := range users {
+			if strings.EqualFold(user.Email, email) {
+				ids[strconv.FormatInt(user.ID, 10)] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no user with email %s in org %s", email, org)
+		}
+	}
+	return ids, nil
+}
+
+func expandFlowMembers(set *schema.Set) []string {
+	var members []string
+	for _, member := range set.List() {
+		members = append(members, member.(string))
+	}
+	return members
+}
+
+func syncFlowMembers(ctx context.Context, d *schema.ResourceData, apiClient *Client, org string, flow string) error {
+	desired, err := resolveFlowMembers(ctx, apiClient, org, expandFlowMembers(d.Get("members").(*schema.Set)))
+	if err != nil {
+		return err
+	}
+	users, err := apiClient.listFlowUsers(ctx, org, flow)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]bool)
+	for _, user := range users {
+		current[strconv.FormatInt(user.ID, 10)] = true
+	}
+
+	for id := range desired {
+		if !current[id] {
+			log.Printf("[DEBUG] adding user %s to flow %s/%s", id, org, flow)
+			if err := apiClient.addUserToFlow(ctx, org, flow, id); err != nil {
+				return fmt.Errorf("adding user %s to flow %s/%s failed: %w", id, org, flow, err)
+			}
+		}
+	}
+	for id := range current {
+		if !desired[id] {
+			log.Printf("[DEBUG] removing user %s from flow %s/%s", id, org, flow)
+			if err := apiClient.removeUserFromFlow(ctx, org, flow, id); err != nil && !IsNotFound(err) {
+				return fmt.Errorf("removing user %s from flow %s/%s failed: %w", id, org, flow, err)
+			}
+		}
+	}
+	return nil
+}
+
+func flowMembersCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org := d.Get("org").(string)
+	flow := d.Get("flow").(string)
+
+	if err := syncFlowMembers(ctx, d, apiClient, org, flow); err != nil {
+		return attributeError("members", err)
+	}
+	d.SetId(fmt.Sprintf("%s/%s", org, flow))
+	return flowMembersRead(ctx, d, meta)
+}
+
+func flowMembersRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, flow, err := parseFlowId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	users, err := apiClient.listFlowUsers(ctx, org, flow)
+	if IsNotFound(err) {
+		log.Printf("[WARN] flow %s not found, removing members from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// keep members declared by email as emails so that only real changes
+	// show up in the plan, everyone else is reported by user id
+	emails := make(map[string]string)
+	if v, ok := d.GetOk("members"); ok {
+		for _, member := range expandFlowMembers(v.(*schema.Set)) {
+			if isEmailMember(member) {
+				emails[strings.ToLower(member)] = member
+			}
+		}
+	}
+	var members []interface{}
+	for _, user := range users {
+		if email, ok := emails[strings.ToLower(user.Email)]; ok {
+			members = append(members, email)
+		} else {
+			members = append(members, strconv.FormatInt(user.ID, 10))
+		}
+	}
+
+	d.Set("org", org)
+	d.Set("flow", flow)
+	if err := d.Set("members", schema.NewSet(schema.HashString, members)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func flowMembersUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, flow, err := parseFlowId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if d.HasChange("members") {
+		if err := syncFlowMembers(ctx, d, apiClient, org, flow); err != nil {
+			return attributeError("members", err)
+		}
+	}
+	return flowMembersRead(ctx, d, meta)
+}
+
+// Only the declared members are removed, the flow itself is left untouched.
+func flowMembersDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, flow, err := parseFlowId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	ids, err := resolveFlowMembers(ctx, apiClient, org, expandFlowMembers(d.Get("members").(*schema.Set)))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	for id := range ids {
+		if err := apiClient.removeUserFromFlow(ctx, org, flow, id); err != nil && !IsNotFound(err) {
+			return diag.FromErr(fmt.Errorf("removing user %s from flow %s/%s failed: %w", id, org, flow, err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package flowdock
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_resolveFlowMembers_Should_Look_Up_Emails_In_Org(t *testing.T) {
+	output, _ := json.Marshal([]User{
+		{ID: 123456, Email: "xxxxx@fairfaxmedia.co.nz"},
+		{ID: 654321, Email: "yyyyy@fairfaxmedia.co.nz"},
+	})
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/organizations/org/users", req.URL.Path)
+		res.WriteHeader(http.StatusOK)
+		res.Write(output)
+	}))
+	defer ts.Close()
+	client.URL = ts.URL
+
+	ids, err := resolveFlowMembers(context.Background(), client, "org", []string{"111", "YYYYY@fairfaxmedia.co.nz"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"111": true, "654321": true}, ids)
+
+	_, err = resolveFlowMembers(context.Background(), client, "org", []string{"zzzzz@fairfaxmedia.co.nz"})
+	assert.Error(t, err)
+}
+
+func Test_flowMembers_Should_Sync_With_Live_Flow_Users_And_Report_Drift(t *testing.T) {
+	orgUsers := []User{
+		{ID: 1, Email: "xxxxx@fairfaxmedia.co.nz"},
+		{ID: 2, Email: "yyyyy@fairfaxmedia.co.nz"},
+		{ID: 3, Email: "zzzzz@fairfaxmedia.co.nz"},
+		{ID: 4, Email: "wwwww@fairfaxmedia.co.nz"},
+	}
+	flowUsers := map[int64]bool{1: true, 3: true}
+	var calls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/organizations/org/users":
+			output, _ := json.Marshal(orgUsers)
+			res.Write(output)
+		case req.Method == "GET" && req.URL.Path == "/flows/org/flow/users":
+			var users []User
+			for _, user := range orgUsers {
+				if flowUsers[user.ID] {
+					users = append(users, user)
+				}
+			}
+			output, _ := json.Marshal(users)
+			res.Write(output)
+		case req.Method == "POST" && req.URL.Path == "/flows/org/flow/users":
+			req.ParseForm()
+			calls = append(calls, "POST "+req.PostForm.Get("id"))
+			id, _ := strconv.ParseInt(req.PostForm.Get("id"), 10, 64)
+			flowUsers[id] = true
+			res.Write([]byte("{}"))
+		case req.Method == "DELETE":
+			calls = append(calls, "DELETE "+req.URL.Path)
+			id, _ := strconv.ParseInt(req.URL.Path[len("/flows/org/flow/users/"):], 10, 64)
+			delete(flowUsers, id)
+		default:
+			t.Errorf("unexpected %s %s", req.Method, req.URL.Path)
+			res.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	client, _ := NewClient("apiKey")
+	client.setBaseURL(ts.URL)
+	client.setUserCacheTTL(0)
+
+	d := schema.TestResourceDataRaw(t, ResourceFlowMembers().Schema, map[string]interface{}{
+		"org":     "org",
+		"flow":    "flow",
+		"members": []interface{}{"XXXXX@fairfaxmedia.co.nz", "2"},
+	})
+	diags := flowMembersCreate(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, []string{"POST 2", "DELETE /flows/org/flow/users/3"}, calls)
+	assert.Equal(t, "org/flow", d.Id())
+
+	// someone added by hand shows up by id, members declared by email stay emails
+	flowUsers[4] = true
+	diags = flowMembersRead(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	members := expandFlowMembers(d.Get("members").(*schema.Set))
+	sort.Strings(members)
+	assert.Equal(t, []string{"2", "4", "XXXXX@fairfaxmedia.co.nz"}, members)
+}
@@ -0,0 +1,126 @@
+package flowdock
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func ResourceFlowMembership() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: flowMembershipCreate,
+		ReadContext:   flowMembershipRead,
+		DeleteContext: flowMembershipDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"org": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"flow": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"user_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"email": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"nick": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// membership ids are stored as org/flow/user_id
+func parseFlowMembershipId(id string) (string, string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("unexpected flow membership id %q, expected org/flow/user_id", id)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+func flowMembershipCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org := d.Get("org").(string)
+	flow := d.Get("flow").(string)
+	userId := d.Get("user_id").(string)
+
+	if err := apiClient.addUserToFlow(ctx, org, flow, userId); err != nil {
+		return diag.FromErr(fmt.Errorf("flowMembershipCreate failed: %w", err))
+	}
+	d.SetId(fmt.Sprintf("%s/%s/%s", org, flow, userId))
+	return flowMembershipRead(ctx, d, meta)
+}
+
+func flowMembershipRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, flow, userId, err := parseFlowMembershipId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	users, err := apiClient.listFlowUsers(ctx, org, flow)
+	if IsNotFound(err) {
+		log.Printf("[WARN] flow %s/%s not found, removing membership %s from state", org, flow, d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	for _, user := range users {
+		if strconv.FormatInt(user.ID, 10) == userId {
+			d.Set("org", org)
+			d.Set("flow", flow)
+			d.Set("user_id", userId)
+			d.Set("email", user.Email)
+			d.Set("name", user.Name)
+			d.Set("nick", user.Nick)
+			return nil
+		}
+	}
+	log.Printf("[WARN] user %s has left flow %s/%s, removing membership from state", userId, org, flow)
+	d.SetId("")
+	return nil
+}
+
+func flowMembershipDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, flow, userId, err := parseFlowMembershipId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = apiClient.removeUserFromFlow(ctx, org, flow, userId)
+	if err != nil && !IsNotFound(err) {
+		return diag.FromErr(fmt.Errorf("flowMembershipDelete failed: %w", err))
+	}
+	return nil
+}
@@ -1,33 +1,143 @@
 package flowdock
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 // invitations resource, as seen by GET /invitations
 type Invitation struct {
-	ID      int64  `json:"id"`
-	Email   string `json:"email"`
-	State   string `json:"state"`
-	URL     string `json:"url"`
-	MESSAGE string `json:"message"`
+	ID        int64  `json:"id"`
+	Email     string `json:"email"`
+	State     string `json:"state"`
+	URL       string `json:"url"`
+	MESSAGE   string `json:"message"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
 }
 
+// states of flowdock_invitation, pending and accepted come from Flowdock
+const (
+	invitationPending  = "pending"
+	invitationAccepted = "accepted"
+	// the user was already a member of the organization, nobody was invited
+	invitationMember = "member"
+)
+
+const (
+	// fits into the default create timeout, together with acceptanceWaitMargin
+	defaultAcceptanceTimeout      = 4 * time.Minute
+	defaultAcceptancePollInterval = 30 * time.Second
+)
+
 func ResourceInvitation() *schema.Resource {
 	return &schema.Resource{
-		Create: invitationCreate,
-		Read:   invitationRead,
-		Update: invitationUpdate,
-		Delete: invitationDelete,
+		CreateContext: invitationCreate,
+		ReadContext:   invitationRead,
+		UpdateContext: invitationUpdate,
+		DeleteContext: invitationDelete,
+		CustomizeDiff: invitationCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceInvitationV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: invitationStateUpgradeV0,
+			},
+		},
+		Schema: map[string]*schema.Schema{
+			"email": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+				// Flowdock may return the address in a different case
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return strings.EqualFold(old, new)
+				},
+			},
+			"org": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"flow": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"message": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "changing it revokes a pending invitation and sends a new one",
+			},
+			"resend_trigger": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "any change sends a pending invitation again",
+			},
+			"wait_for_acceptance": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "block the creation until the invitation has been accepted",
+			},
+			"acceptance_timeout": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(defaultAcceptanceTimeout / time.Second),
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "seconds to wait for the invitation to be accepted, limited by timeouts.create",
+			},
+			"acceptance_poll_interval": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(defaultAcceptancePollInterval / time.Second),
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "seconds between two checks of the invitation state",
+			},
+			"state": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"invitation_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"user_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"accepted_at": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 		},
+	}
+}
+
+// resourceInvitationV0 is the schema before the invitation lifecycle was
+// tracked, its id was either the invitation id or the user id.
+func resourceInvitationV0() *schema.Resource {
+	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"email": &schema.Schema{
 				Type:     schema.TypeString,
@@ -50,81 +160,248 @@ func ResourceInvitation() *schema.Resource {
 	}
 }
 
-func invitationCreate(d *schema.ResourceData, meta interface{}) error {
+// invitationStateUpgradeV0 keeps the old id as invitation_id, invitationRead
+// drops it again when it turns out to be a user id.
+func invitationStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	org, _ := rawState["org"].(string)
+	flow, _ := rawState["flow"].(string)
+	email, _ := rawState["email"].(string)
+	if id, ok := rawState["id"].(string); ok {
+		rawState["invitation_id"] = id
+	}
+	rawState["id"] = invitationId(org, flow, email)
+	rawState["wait_for_acceptance"] = false
+	rawState["acceptance_timeout"] = int(defaultAcceptanceTimeout / time.Second)
+	rawState["acceptance_poll_interval"] = int(defaultAcceptancePollInterval / time.Second)
+	return rawState, nil
+}
+
+// invitation ids are stored as org/flow/email, which stays the same while the
+// invitation is pending and after it has been accepted
+func invitationId(org string, flow string, email string) string {
+	return fmt.Sprintf("%s/%s/%s", org, flow, email)
+}
+
+func parseInvitationId(id string) (string, string, string, error) {
+	parts := strings.SplitN(id, "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("unexpected invitation id %q, expected org/flow/email", id)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+func invitationCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	apiClient := meta.(*Client)
 	org := d.Get("org").(string)
 	email := d.Get("email").(string)
 	flow := d.Get("flow").(string)
 	message := d.Get("message").(string)
 
-	userId, errorE := apiClient.getUserIdByEmail(org, email)
+	userId, errorE := apiClient.getUserIdByEmail(ctx, org, email)
 
-	if errorE != nil && errorE.Error() != missMatchEmail {
+	if errorE != nil && !IsNotFound(errorE) {
 		log.Printf("invitationCreate communications between client and server error")
-		return nil
+		return diag.FromErr(fmt.Errorf("invitationCreate failed to look up %s: %w", email, errorE))
 	}
 	if len(userId) > 0 {
-		d.SetId(userId)
-		return nil
+		d.SetId(invitationId(org, flow, email))
+		d.Set("invitation_id", "")
+		diags := invitationRead(ctx, d, meta)
+		return append(diags, diag.Diagnostic{
+			Severity:      diag.Warning,
+			Summary:       fmt.Sprintf("%s is already a member of %s, no invitation was sent", email, org),
+			AttributePath: cty.GetAttrPath("email"),
+		})
 	}
 
 	d.Set("message", message)
 
-	invitation, error := apiClient.inviteNewUser(email, message, org, flow)
+	invitation, error := apiClient.inviteNewUser(ctx, email, message, org, flow)
 	if error != nil {
-		return fmt.Errorf("invitationCreate failed, response: %s", error)
+		return diag.FromErr(fmt.Errorf("invitationCreate failed, response: %w", error))
 	}
 
-	d.SetId(strconv.FormatInt(invitation.ID, 10))
-	return nil
+	d.SetId(invitationId(org, flow, email))
+	d.Set("invitation_id", strconv.FormatInt(invitation.ID, 10))
+
+	if d.Get("wait_for_acceptance").(bool) {
+		timeout := time.Duration(d.Get("acceptance_timeout").(int)) * time.Second
+		interval := time.Duration(d.Get("acceptance_poll_interval").(int)) * time.Second
+		_, err := apiClient.waitForInvitationAccepted(ctx, org, flow, d.Get("invitation_id").(string), timeout, interval)
+		if err != nil {
+			diags := invitationRead(ctx, d, meta)
+			return append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       fmt.Sprintf("%s did not accept the invitation to %s/%s", email, org, flow),
+				Detail:        err.Error(),
+				AttributePath: cty.GetAttrPath("wait_for_acceptance"),
+			})
+		}
+	}
+	return invitationRead(ctx, d, meta)
 }
 
-func invitationRead(d *schema.ResourceData, meta interface{}) error {
+func invitationRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	apiClient := meta.(*Client)
 
 	// imported data format:userId_flowName_orgName
-	if strings.Contains(d.Id(), "_") {
+	if !strings.Contains(d.Id(), "/") && strings.Contains(d.Id(), "_") {
 		userInfo := strings.Split(d.Id(), "_")
+		if len(userInfo) != 3 {
+			return diag.Errorf("unexpected invitation id %q, expected org/flow/email or userId_flowName_orgName", d.Id())
+		}
 		userId, flow, org := userInfo[0], userInfo[1], userInfo[2]
 
-		url := fmt.Sprintf("%s/users/%s", apiClient.URL, userId)
-		res, err := apiClient.Http.Get(url)
+		user, err := apiClient.getUserById(ctx, userId)
 		if err != nil {
 			log.Printf("invitationRead error,get user error:%v", err)
-			return err
+			return diag.FromErr(err)
 		}
-		defer res.Body.Close()
-		user := &User{}
-		json.NewDecoder(res.Body).Decode(user)
 
-		d.SetId(userId)
+		d.SetId(invitationId(org, flow, user.Email))
 		d.Set("org", org)
 		d.Set("flow", flow)
 		d.Set("email", user.Email)
 		d.Set("message", user.Name)
 	}
-	d.SetId(d.Id())
+
+	org, flow, email, err := parseInvitationId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	state, acceptedAt := "", ""
+	if id := d.Get("invitation_id").(string); id != "" {
+		invitation, err := apiClient.getInvitationByInviteId(ctx, org, flow, id)
+		switch {
+		case err != nil && !IsNotFound(err):
+			return diag.FromErr(fmt.Errorf("invitationRead failed: %w", err))
+		case err != nil || !strings.EqualFold(invitation.Email, email):
+			// ids of the previous schema version may be user ids
+			log.Printf("[DEBUG] %s is not an invitation for %s", id, email)
+			d.Set("invitation_id", "")
+		default:
+			state = invitation.State
+			d.Set("email", invitation.Email)
+			if state == invitationAccepted {
+				acceptedAt = invitation.UpdatedAt
+			} else {
+				// accepted invitations keep the message of the configuration
+				d.Set("message", invitation.MESSAGE)
+			}
+		}
+	}
+
+	userId, err := apiClient.getUserIdByEmail(ctx, org, email)
+	if err != nil && !IsNotFound(err) {
+		return diag.FromErr(fmt.Errorf("invitationRead failed to look up %s: %w", email, err))
+	}
+	if state == "" && userId != "" {
+		state = invitationMember
+	}
+	// imported by email, the invitation id isn't known yet
+	if state == "" {
+		invitations, err := apiClient.listInvitations(ctx, org, flow)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("invitationRead failed to list invitations: %w", err))
+		}
+		for _, invitation := range invitations {
+			if strings.EqualFold(invitation.Email, email) && invitation.State == invitationPending {
+				d.Set("invitation_id", strconv.FormatInt(invitation.ID, 10))
+				d.Set("email", invitation.Email)
+				d.Set("message", invitation.MESSAGE)
+				state = invitation.State
+				break
+			}
+		}
+	}
+
+	// revoked or expired invitations, and users who left the organization
+	// after accepting, have to be invited again
+	if userId == "" && state != invitationPending {
+		log.Printf("[WARN] neither a pending invitation nor a user found for %s in %s/%s, removing it from state",
+			email, org, flow)
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("org", org)
+	d.Set("flow", flow)
+	d.Set("state", state)
+	d.Set("user_id", userId)
+	d.Set("accepted_at", acceptedAt)
 	return nil
 }
 
-func invitationUpdate(d *schema.ResourceData, m interface{}) error {
-	return invitationRead(d, m)
+// invitationCustomizeDiff shows in the plan that a pending invitation is
+// going to be replaced by a new one.
+func invitationCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || d.Get("state").(string) != invitationPending {
+		return nil
+	}
+	if d.HasChange("message") || d.HasChange("resend_trigger") {
+		return d.SetNewComputed("invitation_id")
+	}
+	return nil
 }
 
-func invitationDelete(d *schema.ResourceData, meta interface{}) error {
+func invitationUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if !d.HasChange("message") && !d.HasChange("resend_trigger") {
+		return invitationRead(ctx, d, meta)
+	}
 	apiClient := meta.(*Client)
 	org := d.Get("org").(string)
 	flow := d.Get("flow").(string)
 	email := d.Get("email").(string)
-	// Id is invitation id and the user has accepted the invitatoin, delete by email
-	userId, errorE := apiClient.getUserIdByEmail(org, email)
-	// If the user isn't exist in the org,the id must be invitation Id, delete by id
-	if errorE != nil && errorE.Error() == missMatchEmail {
-		apiClient.deleteInvitationById(org, flow, d.Id())
+
+	// accepted invitations can't be changed anymore, the message is only kept in state
+	if d.Get("state").(string) != invitationPending {
+		diags := invitationRead(ctx, d, meta)
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("%s is already a member of %s, the invitation was not sent again", email, org),
+		})
+	}
+
+	// the plan shows the new invitation id as unknown
+	inviteId, _ := d.GetChange("invitation_id")
+	invitation, err := apiClient.resendInvitation(ctx, org, flow, inviteId.(string), email, d.Get("message").(string))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("invitationUpdate failed to resend the invitation to %s: %w", email, err))
+	}
+	d.Set("invitation_id", strconv.FormatInt(invitation.ID, 10))
+	return invitationRead(ctx, d, meta)
+}
+
+func invitationDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org := d.Get("org").(string)
+	flow := d.Get("flow").(string)
+	email := d.Get("email").(string)
+	// nobody was invited, the user was in the organization before
+	if d.Get("state").(string) == invitationMember {
+		log.Printf("[INFO] %s was already a member of %s, only removing the invitation from state", email, org)
 		return nil
-	} else if len(userId) > 0 {
-		// User exists in the org but the id is invitation Id, need to delete by userId
-		apiClient.deleteUserFromOrg(org, userId)
+	}
+	// the invitation may have been accepted since the last refresh, look the user up again
+	userId, errorE := apiClient.getUserIdByEmail(ctx, org, email)
+	// If the user isn't in the org, only the pending invitation has to go
+	if IsNotFound(errorE) {
+		id := d.Get("invitation_id").(string)
+		if id == "" {
+			return nil
+		}
+		err := apiClient.deleteInvitationById(ctx, org, flow, id)
+		if err != nil && !IsNotFound(err) {
+			return diag.FromErr(fmt.Errorf("invitationDelete failed: %w", err))
+		}
+		return nil
+	} else if errorE != nil {
+		return diag.FromErr(fmt.Errorf("invitationDelete failed to look up %s: %w", email, errorE))
+	}
+	err := apiClient.deleteUserFromOrg(ctx, org, userId)
+	if err != nil && !IsNotFound(err) {
+		return diag.FromErr(fmt.Errorf("invitationDelete failed: %w", err))
 	}
 	return nil
 }
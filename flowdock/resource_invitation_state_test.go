@@ -0,0 +1,392 @@
+package flowdock
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// invitationServer fakes the organization users and the invitations of
+// org/flow.
+type invitationServer struct {
+	users       []User
+	invitations []Invitation
+	sent        int64
+	deleted     []string
+}
+
+func (server *invitationServer) start(t *testing.T) (*Client, func()) {
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var out interface{}
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/organizations/org/users":
+			out = server.users
+		case req.Method == "GET" && req.URL.Path == "/flows/org/flow/invitations":
+			out = server.invitations
+		case req.Method == "POST" && req.URL.Path == "/flows/org/flow/invitations":
+			req.ParseForm()
+			invitation := Invitation{ID: 1000 + server.sent, Email: req.PostForm.Get("email"),
+				State: invitationPending, MESSAGE: req.PostForm.Get("message")}
+			server.invitations = append(server.invitations, invitation)
+			server.sent++
+			out = invitation
+		default:
+			if req.Method == "DELETE" {
+				server.deleted = append(server.deleted, req.URL.Path)
+			}
+			for i, invitation := range server.invitations {
+				if req.URL.Path != invitationPath(invitation.ID) {
+					continue
+				}
+				out = invitation
+				if req.Method == "DELETE" {
+					server.invitations = append(server.invitations[:i], server.invitations[i+1:]...)
+				}
+				break
+			}
+		}
+		if out == nil {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+		output, _ := json.Marshal(out)
+		res.Write(output)
+	}))
+	client, _ := NewClient("MOCKED_API_KEY")
+	client.setBaseURL(ts.URL)
+	client.setUserCacheTTL(0)
+	return client, ts.Close
+}
+
+func invitationPath(id int64) string {
+	output, _ := json.Marshal(id)
+	return "/flows/org/flow/invitations/" + string(output)
+}
+
+func newInvitationData(t *testing.T) *schema.ResourceData {
+	return schema.TestResourceDataRaw(t, ResourceInvitation().Schema, map[string]interface{}{
+		"org":     "org",
+		"flow":    "flow",
+		"email":   "xxxxx@fairfaxmedia.co.nz",
+		"message": "welcome",
+	})
+}
+
+func Test_invitation_Should_Keep_Id_From_Pending_To_Accepted(t *testing.T) {
+	server := &invitationServer{}
+	client, stop := server.start(t)
+	defer stop()
+
+	d := newInvitationData(t)
+	diags := invitationCreate(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, "org/flow/xxxxx@fairfaxmedia.co.nz", d.Id())
+	assert.Equal(t, "1000", d.Get("invitation_id"))
+	assert.Equal(t, invitationPending, d.Get("state"))
+	assert.Equal(t, "", d.Get("user_id"))
+
+	server.invitations[0].State = invitationAccepted
+	server.invitations[0].UpdatedAt = "2020-01-02T03:04:05Z"
+	server.users = []User{{ID: 123456, Email: "xxxxx@fairfaxmedia.co.nz"}}
+
+	diags = invitationRead(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, "org/flow/xxxxx@fairfaxmedia.co.nz", d.Id())
+	assert.Equal(t, "1000", d.Get("invitation_id"))
+	assert.Equal(t, invitationAccepted, d.Get("state"))
+	assert.Equal(t, "123456", d.Get("user_id"))
+	assert.Equal(t, "2020-01-02T03:04:05Z", d.Get("accepted_at"))
+}
+
+func Test_invitationCreate_Should_Not_Invite_Existing_Member(t *testing.T) {
+	server := &invitationServer{users: []User{{ID: 123456, Email: "xxxxx@fairfaxmedia.co.nz"}}}
+	client, stop := server.start(t)
+	defer stop()
+
+	d := newInvitationData(t)
+	diags := invitationCreate(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Len(t, diags, 1, "a warning says nobody was invited")
+	assert.Empty(t, server.invitations)
+	assert.Equal(t, invitationMember, d.Get("state"))
+	assert.Equal(t, "123456", d.Get("user_id"))
+	assert.Equal(t, "", d.Get("invitation_id"))
+}
+
+func Test_invitationStateUpgradeV0_Should_Tell_User_Ids_From_Invitation_Ids(t *testing.T) {
+	server := &invitationServer{
+		users:       []User{{ID: 123456, Email: "xxxxx@fairfaxmedia.co.nz"}},
+		invitations: []Invitation{{ID: 1000, Email: "yyyyy@fairfaxmedia.co.nz", State: invitationPending}},
+	}
+	client, stop := server.start(t)
+	defer stop()
+
+	cases := []struct {
+		oldId         string
+		email         string
+		expectedState string
+		invitationId  string
+	}{
+		{"123456", "xxxxx@fairfaxmedia.co.nz", invitationMember, ""},
+		{"1000", "yyyyy@fairfaxmedia.co.nz", invitationPending, "1000"},
+	}
+	for _, c := range cases {
+		state, err := invitationStateUpgradeV0(context.Background(), map[string]interface{}{
+			"id": c.oldId, "org": "org", "flow": "flow", "email": c.email,
+		}, client)
+		assert.NoError(t, err)
+		assert.Equal(t, "org/flow/"+c.email, state["id"])
+
+		d := schema.TestResourceDataRaw(t, ResourceInvitation().Schema, map[string]interface{}{
+			"org": "org", "flow": "flow", "email": c.email,
+		})
+		d.SetId(state["id"].(string))
+		d.Set("invitation_id", state["invitation_id"])
+		diags := invitationRead(context.Background(), d, client)
+		assert.False(t, diags.HasError(), "%v", diags)
+		assert.Equal(t, c.expectedState, d.Get("state"), c.oldId)
+		assert.Equal(t, c.invitationId, d.Get("invitation_id"), c.oldId)
+	}
+}
+
+func Test_invitationRead_Should_Remove_Invitations_Gone_From_Server(t *testing.T) {
+	cases := map[string]func(server *invitationServer){
+		"deleted": func(server *invitationServer) { server.invitations = nil },
+		"revoked": func(server *invitationServer) { server.invitations[0].State = "revoked" },
+		// accepted, but the user has already left the organization
+		"accepted": func(server *invitationServer) { server.invitations[0].State = invitationAccepted },
+	}
+	for name, drift := range cases {
+		server := &invitationServer{}
+		client, stop := server.start(t)
+
+		d := newInvitationData(t)
+		diags := invitationCreate(context.Background(), d, client)
+		assert.False(t, diags.HasError(), "%v", diags)
+
+		drift(server)
+		diags = invitationRead(context.Background(), d, client)
+		assert.False(t, diags.HasError(), "%v", diags)
+		assert.Equal(t, "", d.Id(), name)
+		stop()
+	}
+}
+
+func Test_invitationRead_Should_Refresh_Email_And_Message(t *testing.T) {
+	server := &invitationServer{}
+	client, stop := server.start(t)
+	defer stop()
+
+	d := newInvitationData(t)
+	diags := invitationCreate(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, "welcome", d.Get("message"))
+
+	server.invitations[0].Email = "XXXXX@fairfaxmedia.co.nz"
+	server.invitations[0].MESSAGE = "changed in the web app"
+	diags = invitationRead(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, "org/flow/xxxxx@fairfaxmedia.co.nz", d.Id())
+	assert.Equal(t, "XXXXX@fairfaxmedia.co.nz", d.Get("email"))
+	assert.Equal(t, "changed in the web app", d.Get("message"))
+}
+
+// applyInvitation refreshes state, then plans and applies config on top of it
+// like terraform does.
+func applyInvitation(t *testing.T, client *Client, state *terraform.InstanceState,
+	config map[string]interface{}) (*terraform.InstanceState, diag.Diagnostics) {
+
+	resource := ResourceInvitation()
+	if state != nil {
+		var diags diag.Diagnostics
+		state, diags = resource.RefreshWithoutUpgrade(context.Background(), state, client)
+		assert.False(t, diags.HasError(), "%v", diags)
+	}
+	diff, err := resource.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+	assert.NoError(t, err)
+	return resource.Apply(context.Background(), state, diff, client)
+}
+
+func Test_invitationUpdate_Should_Resend_Pending_Invitation_When_Trigger_Changes(t *testing.T) {
+	server := &invitationServer{}
+	client, stop := server.start(t)
+	defer stop()
+
+	config := map[string]interface{}{
+		"org": "org", "flow": "flow", "email": "xxxxx@fairfaxmedia.co.nz", "resend_trigger": "1",
+	}
+	state, diags := applyInvitation(t, client, nil, config)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, "1000", state.Attributes["invitation_id"])
+
+	config["resend_trigger"] = "2"
+	state, diags = applyInvitation(t, client, state, config)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, "org/flow/xxxxx@fairfaxmedia.co.nz", state.ID)
+	assert.Equal(t, "1001", state.Attributes["invitation_id"])
+	assert.Equal(t, invitationPending, state.Attributes["state"])
+	assert.Len(t, server.invitations, 1, "the first invitation was revoked")
+}
+
+func Test_invitationUpdate_Should_Reissue_Pending_Invitation_With_New_Message(t *testing.T) {
+	server := &invitationServer{}
+	client, stop := server.start(t)
+	defer stop()
+
+	config := map[string]interface{}{
+		"org": "org", "flow": "flow", "email": "xxxxx@fairfaxmedia.co.nz", "message": "Gyles Polloso",
+	}
+	state, diags := applyInvitation(t, client, nil, config)
+	assert.False(t, diags.HasError(), "%v", diags)
+
+	config["message"] = "Post-Gyles"
+	diff, err := ResourceInvitation().Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+	assert.NoError(t, err)
+	assert.True(t, diff.Attributes["invitation_id"].NewComputed, "the plan shows the invitation is replaced")
+	assert.False(t, diff.RequiresNew())
+
+	state, diags = applyInvitation(t, client, state, config)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, "1001", state.Attributes["invitation_id"])
+	assert.Equal(t, "Post-Gyles", state.Attributes["message"])
+	assert.Equal(t, "Post-Gyles", server.invitations[0].MESSAGE)
+	assert.Len(t, server.invitations, 1)
+}
+
+func Test_invitationUpdate_Should_Keep_Message_Of_Accepted_Invitation_In_State(t *testing.T) {
+	server := &invitationServer{}
+	client, stop := server.start(t)
+	defer stop()
+
+	config := map[string]interface{}{
+		"org": "org", "flow": "flow", "email": "xxxxx@fairfaxmedia.co.nz", "message": "Gyles Polloso",
+	}
+	state, diags := applyInvitation(t, client, nil, config)
+	assert.False(t, diags.HasError(), "%v", diags)
+	server.invitations[0].State = invitationAccepted
+	server.users = []User{{ID: 123456, Email: "xxxxx@fairfaxmedia.co.nz"}}
+
+	config["message"] = "Post-Gyles"
+	state, diags = applyInvitation(t, client, state, config)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Len(t, diags, 1, "a warning says nothing was sent")
+	assert.Equal(t, "1000", state.Attributes["invitation_id"])
+	assert.Equal(t, "Post-Gyles", state.Attributes["message"])
+
+	diff, err := ResourceInvitation().Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+	assert.NoError(t, err)
+	assert.True(t, diff.Empty(), "no perpetual diff: %v", diff)
+}
+
+func Test_invitation_Should_Be_Replaced_When_Email_Org_Or_Flow_Changes(t *testing.T) {
+	server := &invitationServer{}
+	client, stop := server.start(t)
+	defer stop()
+
+	config := map[string]interface{}{"org": "org", "flow": "flow", "email": "a@x.com"}
+	state, diags := applyInvitation(t, client, nil, config)
+	assert.False(t, diags.HasError(), "%v", diags)
+
+	for attribute, value := range map[string]string{"email": "b@x.com", "org": "other-org", "flow": "other-flow"} {
+		changed := map[string]interface{}{"org": "org", "flow": "flow", "email": "a@x.com"}
+		changed[attribute] = value
+		diff, err := ResourceInvitation().Diff(context.Background(), state, terraform.NewResourceConfigRaw(changed), client)
+		assert.NoError(t, err)
+		assert.True(t, diff.RequiresNew(), attribute)
+	}
+
+	// a different case is the same address
+	config["email"] = "A@x.com"
+	diff, err := ResourceInvitation().Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+	assert.NoError(t, err)
+	assert.True(t, diff.Empty(), "%v", diff)
+
+	// terraform replaces the resource: the old invitation is revoked and the new address invited
+	diags = invitationDelete(context.Background(), ResourceInvitation().Data(state), client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	config["email"] = "b@x.com"
+	state, diags = applyInvitation(t, client, nil, config)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, "org/flow/b@x.com", state.ID)
+	assert.Equal(t, "b@x.com", state.Attributes["email"])
+	assert.Equal(t, int64(2), server.sent)
+	assert.Len(t, server.invitations, 1)
+}
+
+func Test_invitationDelete_Should_Keep_Existing_Members_In_Org(t *testing.T) {
+	server := &invitationServer{users: []User{{ID: 123456, Email: "xxxxx@fairfaxmedia.co.nz"}}}
+	client, stop := server.start(t)
+	defer stop()
+
+	d := newInvitationData(t)
+	diags := invitationCreate(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, invitationMember, d.Get("state"))
+
+	diags = invitationDelete(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Empty(t, server.deleted)
+}
+
+func Test_invitationDelete_Should_Remove_Users_Who_Accepted_From_Org(t *testing.T) {
+	server := &invitationServer{}
+	client, stop := server.start(t)
+	defer stop()
+
+	d := newInvitationData(t)
+	diags := invitationCreate(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	server.invitations[0].State = invitationAccepted
+	server.users = []User{{ID: 123456, Email: "xxxxx@fairfaxmedia.co.nz"}}
+
+	diags = invitationDelete(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, []string{"/organizations/org/users/123456"}, server.deleted)
+}
+
+func Test_invitationRead_Should_Keep_Accepted_Invitation_Of_User_Listed_In_Other_Case(t *testing.T) {
+	server := &invitationServer{}
+	client, stop := server.start(t)
+	defer stop()
+
+	d := newInvitationData(t)
+	diags := invitationCreate(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	server.invitations[0].State = invitationAccepted
+	server.users = []User{{ID: 123456, Email: "XXXXX@FairfaxMedia.co.nz"}}
+
+	diags = invitationRead(context.Background(), d, client)
+	assert.False(t, diags.HasError(), "%v", diags)
+	assert.Equal(t, "org/flow/xxxxx@fairfaxmedia.co.nz", d.Id())
+	assert.Equal(t, invitationAccepted, d.Get("state"))
+	assert.Equal(t, "123456", d.Get("user_id"))
+}
+
+func Test_invitationRead_Should_Reject_Malformed_Legacy_Import_Ids(t *testing.T) {
+	server := &invitationServer{}
+	client, stop := server.start(t)
+	defer stop()
+
+	for _, id := range []string{"123_flow", "123_1_flow_org"} {
+		d := ResourceInvitation().Data(nil)
+		d.SetId(id)
+		diags := invitationRead(context.Background(), d, client)
+		assert.True(t, diags.HasError(), id)
+	}
+}
+
+func Test_invitation_Default_Acceptance_Timeout_Should_Fit_Into_Create_Timeout(t *testing.T) {
+	create := *ResourceInvitation().Timeouts.Create
+	acceptance := time.Duration(ResourceInvitation().Schema["acceptance_timeout"].Default.(int)) * time.Second
+
+	assert.True(t, acceptance+acceptanceWaitMargin <= create,
+		"acceptance_timeout %s doesn't fit into the create timeout %s", acceptance, create)
+}
@@ -1,12 +1,13 @@
 package flowdock
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
-	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 const (
@@ -18,9 +19,9 @@ func TestAccFlowdock_Invite_One_User(t *testing.T) {
 	resourceName := "flowdock_invitation.sirenfei_robot_1_test-terraform"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: checkItemDestroy,
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviders,
+		CheckDestroy:      checkItemDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: checkItemBasic(clientVersion, os.Getenv("FLOWDOCK_TOKEN")),
@@ -56,9 +57,9 @@ func TestAccFlowdock_Invitation_Import_User_Resource(t *testing.T) {
 	resourceName := "flowdock_invitation.richard-xue_1_test-terraform"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: checkItemDestroy,
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviders,
+		CheckDestroy:      checkItemDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: checkItemImportBasic(clientVersion, os.Getenv("FLOWDOCK_TOKEN")),
@@ -94,9 +95,9 @@ func checkItemImportBasic(token string, version string) string {
 func TestAccFlowdock_Invitation_Update_Resource(t *testing.T) {
 	resourceName := "flowdock_invitation.gyles-polloso_1_test-terraform"
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: checkItemDestroy,
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviders,
+		CheckDestroy:      checkItemDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: checkItemPreUpdate(clientVersion, os.Getenv("FLOWDOCK_TOKEN")),
@@ -156,9 +157,9 @@ func TestAccFlowdock_Invite_Multiple_Resources(t *testing.T) {
 	resourceName2 := "flowdock_invitation.damian-mackle_1_test-terraform"
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: checkItemDestroy,
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviders,
+		CheckDestroy:      checkItemDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCheckFlowdockMultiple(clientVersion, os.Getenv("FLOWDOCK_TOKEN")),
@@ -202,8 +203,8 @@ func testAccCheckFlowdockItemExists(resource string, org string, flow string) re
 		if rs.Primary.ID == "" {
 			return fmt.Errorf("No Resource ID is set")
 		}
-		id := rs.Primary.ID
-		isExist := isInvitationOrUserExistsInFlowdockServer(org, flow, id)
+		isExist := isInvitationOrUserExistsInFlowdockServer(org, flow,
+			rs.Primary.Attributes["user_id"], rs.Primary.Attributes["invitation_id"])
 		if isExist == false {
 			return fmt.Errorf("error testAccCheckFlowdockItemExists with resource %s", resource)
 		}
@@ -224,7 +225,8 @@ func checkItemDestroy(state *terraform.State) error {
 		org := item.Primary.Attributes["org"]
 		flow := item.Primary.Attributes["flow"]
 
-		isExist := isInvitationOrUserExistsInFlowdockServer(org, flow, id)
+		isExist := isInvitationOrUserExistsInFlowdockServer(org, flow,
+			item.Primary.Attributes["user_id"], item.Primary.Attributes["invitation_id"])
 
 		if isExist == true {
 			return fmt.Errorf("Flowdock user '%s' still exists.", id)
@@ -234,14 +236,16 @@ func checkItemDestroy(state *terraform.State) error {
 	return nil
 }
 
-func isInvitationOrUserExistsInFlowdockServer(org string, flow string, id string) bool {
+func isInvitationOrUserExistsInFlowdockServer(org string, flow string, userId string, invitationId string) bool {
 	client := testAccProvider.Meta().(*Client)
-	_, err := client.getUserById(id)
-	if err == nil {
-		return true
-	} else {
-		_, error := client.getInvitationByInviteId(org, flow, id)
-		if error == nil {
+	if userId != "" {
+		if _, err := client.getOrgUserById(context.Background(), org, userId); err == nil {
+			return true
+		}
+	}
+	if invitationId != "" {
+		invitation, err := client.getInvitationByInviteId(context.Background(), org, flow, invitationId)
+		if err == nil && invitation.State == invitationPending {
 			return true
 		}
 	}
@@ -1,53 +1,157 @@
 package flowdock
 
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 // Organization resource, as seen by GET /organization
 type Organization struct {
-	ID      int64  `json:"id"`
-	APIName string `json:"parameterized_name"`
-	Name    string `json:"name"`
-	APIURL  string `json:"url"`
-	Users   []User `json:"users"` // Maps user ID's to user objects.
-	MESSAGE string `json:"message"`
+	ID           int64        `json:"id"`
+	APIName      string       `json:"parameterized_name"`
+	Name         string       `json:"name"`
+	UserLimit    int          `json:"user_limit"`
+	UserCount    int          `json:"user_count"`
+	Active       bool         `json:"active"`
+	APIURL       string       `json:"url"`
+	Subscription Subscription `json:"subscription"`
+	Users        []User       `json:"users"` // Maps user ID's to user objects.
+	MESSAGE      string       `json:"message"`
+}
+
+// subscription details embedded in the organization resource
+type Subscription struct {
+	Trial     bool   `json:"trial"`
+	TrialEnds string `json:"trial_ends"`
 }
 
+// Organizations can't be created or deleted through the API, so this
+// resource adopts an existing organization by its parameterized name and
+// reconciles the settings that can be changed.
 func ResourceOrganization() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceOrganiztionCreate,
-		Read:   resourceOrganiztionRead,
-		Update: resourceOrganiztionUpdate,
-		Delete: resourceOrganiztionDelete,
+		CreateContext: resourceOrganiztionCreate,
+		ReadContext:   resourceOrganiztionRead,
+		UpdateContext: resourceOrganiztionUpdate,
+		DeleteContext: resourceOrganiztionDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceOrganiztionImport,
+		},
 
 		Schema: map[string]*schema.Schema{
-			"email": &schema.Schema{
+			"parameterized_name": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
+				ForceNew: true,
 			},
-			"message": &schema.Schema{
+			"name": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
+				Computed: true,
+			},
+			"organization_id": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"url": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"user_limit": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"user_count": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"active": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"trial": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"trial_ends": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
 			},
 		},
 	}
 }
 
-func resourceOrganiztionCreate(d *schema.ResourceData, m interface{}) error {
-	email := d.Get("email").(string)
-	d.SetId(email)
-	return resourceOrganiztionRead(d, m)
+func resourceOrganiztionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	apiClient := m.(*Client)
+	org := d.Get("parameterized_name").(string)
+
+	if _, err := apiClient.getOrganization(ctx, org); err != nil {
+		return diag.FromErr(fmt.Errorf("organization %s can't be read, it must exist before it can be managed: %w", org, err))
+	}
+	d.SetId(org)
+
+	if v, ok := d.GetOk("name"); ok {
+		params := url.Values{"name": {v.(string)}}
+		if _, err := apiClient.updateOrganization(ctx, org, params); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return resourceOrganiztionRead(ctx, d, m)
 }
 
-func resourceOrganiztionRead(d *schema.ResourceData, m interface{}) error {
+func resourceOrganiztionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	apiClient := m.(*Client)
+
+	organization, err := apiClient.getOrganization(ctx, d.Id())
+	if IsNotFound(err) {
+		log.Printf("[WARN] organization %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("parameterized_name", organization.APIName)
+	d.Set("name", organization.Name)
+	d.Set("organization_id", organization.ID)
+	d.Set("url", organization.APIURL)
+	d.Set("user_limit", organization.UserLimit)
+	d.Set("user_count", organization.UserCount)
+	d.Set("active", organization.Active)
+	d.Set("trial", organization.Subscription.Trial)
+	d.Set("trial_ends", organization.Subscription.TrialEnds)
 	return nil
 }
 
-func resourceOrganiztionUpdate(d *schema.ResourceData, m interface{}) error {
-	return resourceOrganiztionRead(d, m)
+func resourceOrganiztionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	apiClient := m.(*Client)
+
+	if d.HasChange("name") {
+		params := url.Values{"name": {d.Get("name").(string)}}
+		if _, err := apiClient.updateOrganization(ctx, d.Id(), params); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return resourceOrganiztionRead(ctx, d, m)
+}
+
+// The organization itself is left untouched, it is only removed from state.
+func resourceOrganiztionDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	log.Printf("[INFO] organization %s is no longer managed, it has not been deleted", d.Id())
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("organization %s has not been deleted", d.Id()),
+		Detail:   "Flowdock organizations can't be deleted through the API, it is only no longer managed by terraform.",
+	}}
 }
 
-func resourceOrganiztionDelete(d *schema.ResourceData, m interface{}) error {
-	return nil
+func resourceOrganiztionImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	d.Set("parameterized_name", d.Id())
+	return []*schema.ResourceData{d}, nil
 }
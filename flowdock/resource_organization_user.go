@@ -0,0 +1,123 @@
+package flowdock
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// ResourceOrganizationUser manages the admin role of an existing member of
+// an organization.
+func ResourceOrganizationUser() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: organizationUserCreate,
+		ReadContext:   organizationUserRead,
+		UpdateContext: organizationUserUpdate,
+		DeleteContext: organizationUserDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"org": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"user_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"admin": &schema.Schema{
+				Type:     schema.TypeBool,
+				Required: true,
+			},
+			"email": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// organization user ids are stored as org/user_id
+func parseOrganizationUserId(id string) (string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unexpected organization user id %q, expected org/user_id", id)
+	}
+	return parts[0], parts[1], nil
+}
+
+func organizationUserCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org := d.Get("org").(string)
+	userId := d.Get("user_id").(string)
+
+	if err := apiClient.setOrgUserAdmin(ctx, org, userId, d.Get("admin").(bool)); err != nil {
+		return diag.FromErr(fmt.Errorf("organizationUserCreate failed: %w", err))
+	}
+	d.SetId(fmt.Sprintf("%s/%s", org, userId))
+	return organizationUserRead(ctx, d, meta)
+}
+
+func organizationUserRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, userId, err := parseOrganizationUserId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	user, err := apiClient.getOrgUserById(ctx, org, userId)
+	if IsNotFound(err) {
+		log.Printf("[WARN] user %s is no longer in org %s, removing from state", userId, org)
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("org", org)
+	d.Set("user_id", userId)
+	d.Set("admin", user.Admin)
+	d.Set("email", user.Email)
+	d.Set("name", user.Name)
+	return nil
+}
+
+func organizationUserUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, userId, err := parseOrganizationUserId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if d.HasChange("admin") {
+		if err := apiClient.setOrgUserAdmin(ctx, org, userId, d.Get("admin").(bool)); err != nil {
+			return diag.FromErr(fmt.Errorf("organizationUserUpdate failed: %w", err))
+		}
+	}
+	return organizationUserRead(ctx, d, meta)
+}
+
+// Destroying the resource demotes the user, they stay in the organization.
+func organizationUserDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, userId, err := parseOrganizationUserId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = apiClient.setOrgUserAdmin(ctx, org, userId, false)
+	if err != nil && !IsNotFound(err) {
+		return diag.FromErr(fmt.Errorf("organizationUserDelete failed: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package flowdock
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// integration sources, as seen by GET /flows/:org/:flow/sources/:id
+type Source struct {
+	ID          int64       `json:"id"`
+	Name        string      `json:"name"`
+	ExternalURL string      `json:"external_url"`
+	FlowToken   string      `json:"flow_token"`
+	Application Application `json:"application"`
+	MESSAGE     string      `json:"message"`
+}
+
+// the OAuth application a source belongs to
+type Application struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func ResourceSource() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: sourceCreate,
+		ReadContext:   sourceRead,
+		DeleteContext: sourceDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"org": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"flow": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"application": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"external_url": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"source_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"flow_token": &schema.Schema{
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+		},
+	}
+}
+
+// source ids are stored as org/flow/source_id
+func parseSourceId(id string) (string, string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("unexpected source id %q, expected org/flow/source_id", id)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+func sourceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org := d.Get("org").(string)
+	flow := d.Get("flow").(string)
+
+	params := url.Values{
+		"name": {d.Get("name").(string)},
+	}
+	if v, ok := d.GetOk("application"); ok {
+		params.Set("application", v.(string))
+	}
+	if v, ok := d.GetOk("external_url"); ok {
+		params.Set("external_url", v.(string))
+	}
+
+	source, err := apiClient.createSource(ctx, org, flow, params)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(fmt.Sprintf("%s/%s/%d", org, flow, source.ID))
+	// the flow token may only be returned on creation
+	d.Set("flow_token", source.FlowToken)
+	return sourceRead(ctx, d, meta)
+}
+
+func sourceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, flow, sourceId, err := parseSourceId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	source, err := apiClient.getSource(ctx, org, flow, sourceId)
+	if IsNotFound(err) {
+		log.Printf("[WARN] source %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("org", org)
+	d.Set("flow", flow)
+	d.Set("name", source.Name)
+	d.Set("external_url", source.ExternalURL)
+	d.Set("source_id", sourceId)
+	if source.Application.ID != 0 {
+		d.Set("application", strconv.FormatInt(source.Application.ID, 10))
+	}
+	if source.FlowToken != "" {
+		d.Set("flow_token", source.FlowToken)
+	}
+	return nil
+}
+
+func sourceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, flow, sourceId, err := parseSourceId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = apiClient.deleteSource(ctx, org, flow, sourceId)
+	if err != nil && !IsNotFound(err) {
+		return diag.FromErr(fmt.Errorf("sourceDelete failed: %w", err))
+	}
+	return nil
+}
@@ -1,31 +1,35 @@
 package flowdock
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
-	"net/http"
-	"net/url"
 	"strconv"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 // users resource, as seen by GET /users/:id
 type User struct {
-	ID      int64  `json:"id"`
-	Email   string `json:"email"`
-	Name    string `json:"name"`
-	Nick    string `json:"nick"`
-	MESSAGE string `json:"message"`
+	ID       int64  `json:"id"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Nick     string `json:"nick"`
+	Admin    bool   `json:"admin"`
+	Disabled bool   `json:"disabled"`
+	MESSAGE  string `json:"message"`
 }
 
 func ResourceUser() *schema.Resource {
 	return &schema.Resource{
-		Create: userCreate,
-		Read:   userRead,
-		Update: userUpdate,
-		Delete: userDelete,
+		CreateContext: userCreate,
+		ReadContext:   userRead,
+		UpdateContext: userUpdate,
+		DeleteContext: userDelete,
+
+		DeprecationMessage: "flowdock_user removes the user from the whole organization on destroy, " +
+			"use flowdock_flow_membership to manage flow members instead",
 
 		Schema: map[string]*schema.Schema{
 			"org": &schema.Schema{
@@ -40,45 +44,48 @@ func ResourceUser() *schema.Resource {
 				Type:     schema.TypeString,
 				Required: true,
 			},
+			"email": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"nick": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 		},
 	}
 }
 
-func userCreate(d *schema.ResourceData, meta interface{}) error {
+func userCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	apiClient := meta.(*Client)
 	org := d.Get("org").(string)
 	flow := d.Get("flow").(string)
 	userId := d.Get("user_id").(string)
 
-	params := url.Values{
-		"id": {userId},
-	}
-
-	url := fmt.Sprintf("https://%s@api.flowdock.com/flows/%s/%s/users", apiClient.ApiKey, org, flow)
-
-	res, error := apiClient.Http.PostForm(url, params)
-	if error != nil {
-		log.Printf("error:%v", error)
+	if err := apiClient.addUserToFlow(ctx, org, flow, userId); err != nil {
+		log.Printf("error:%v", err)
+		return diag.FromErr(fmt.Errorf("userCreate failed: %w", err))
 	}
-	defer res.Body.Close()
 	d.SetId(userId)
-	return userRead(d, meta)
+	return userRead(ctx, d, meta)
 }
 
-func userRead(d *schema.ResourceData, meta interface{}) error {
+func userRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	apiClient := meta.(*Client)
 
-	url := fmt.Sprintf("https://%s@api.flowdock.com/users/%s", apiClient.ApiKey, d.Id())
-
-	res, err := apiClient.Http.Get(url)
-
+	user, err := apiClient.getUserById(ctx, d.Id())
+	if IsNotFound(err) {
+		log.Printf("[WARN] user %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	defer res.Body.Close()
-
-	user := &User{}
-	json.NewDecoder(res.Body).Decode(user)
 
 	d.SetId(strconv.FormatInt(user.ID, 10))
 	d.Set("email", user.Email)
@@ -87,29 +94,18 @@ func userRead(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
-func userUpdate(d *schema.ResourceData, meta interface{}) error {
-	return userRead(d, meta)
+func userUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return userRead(ctx, d, meta)
 }
 
-func userDelete(d *schema.ResourceData, meta interface{}) error {
+func userDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	apiClient := meta.(*Client)
 	org := d.Get("org").(string)
 
-	url := fmt.Sprintf("https://%s@api.flowdock.com/organizations/%s/users/%s", apiClient.ApiKey, org, d.Id())
-
-	// Create request
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
+	err := apiClient.deleteUserFromOrg(ctx, org, d.Id())
+	if err != nil && !IsNotFound(err) {
 		log.Printf("user Delete failed")
-		return err
+		return diag.FromErr(err)
 	}
-	// Fetch Request
-	res, err := apiClient.Http.Do(req)
-	if err != nil {
-		log.Printf("user Delete failed")
-		return err
-	}
-	defer res.Body.Close()
-
 	return nil
 }
@@ -0,0 +1,171 @@
+package flowdock
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// outgoing webhooks, as seen by GET /flows/:org/:flow/webhooks/:id
+type Webhook struct {
+	ID      int64    `json:"id"`
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	Active  bool     `json:"active"`
+	MESSAGE string   `json:"message"`
+}
+
+// flow events a webhook can be filtered on
+var webhookEvents = []string{
+	"message", "comment", "status", "file", "activity", "discussion", "action", "tag-change", "message-edit",
+}
+
+func ResourceWebhook() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: webhookCreate,
+		ReadContext:   webhookRead,
+		UpdateContext: webhookUpdate,
+		DeleteContext: webhookDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"org": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"flow": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"url": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// forward every event when empty
+			"events": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(webhookEvents, false),
+				},
+				Set: schema.HashString,
+			},
+			"active": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"webhook_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// webhook ids are stored as org/flow/webhook_id
+func parseWebhookId(id string) (string, string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("unexpected webhook id %q, expected org/flow/webhook_id", id)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+func webhookParams(d *schema.ResourceData) url.Values {
+	params := url.Values{
+		"url":    {d.Get("url").(string)},
+		"active": {fmt.Sprintf("%t", d.Get("active").(bool))},
+	}
+	for _, event := range d.Get("events").(*schema.Set).List() {
+		params.Add("events[]", event.(string))
+	}
+	return params
+}
+
+func webhookCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org := d.Get("org").(string)
+	flow := d.Get("flow").(string)
+
+	webhook, err := apiClient.createWebhook(ctx, org, flow, webhookParams(d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(fmt.Sprintf("%s/%s/%d", org, flow, webhook.ID))
+	return webhookRead(ctx, d, meta)
+}
+
+func webhookRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, flow, webhookId, err := parseWebhookId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	webhook, err := apiClient.getWebhook(ctx, org, flow, webhookId)
+	if IsNotFound(err) {
+		log.Printf("[WARN] webhook %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("org", org)
+	d.Set("flow", flow)
+	d.Set("url", webhook.URL)
+	d.Set("active", webhook.Active)
+	d.Set("webhook_id", webhookId)
+	events := make([]interface{}, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		events = append(events, event)
+	}
+	if err := d.Set("events", schema.NewSet(schema.HashString, events)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func webhookUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, flow, webhookId, err := parseWebhookId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if d.HasChange("url") || d.HasChange("events") || d.HasChange("active") {
+		params := webhookParams(d)
+		// an empty list has to be sent explicitly to clear the filter
+		if len(params["events[]"]) == 0 {
+			params.Set("events[]", "")
+		}
+		if _, err := apiClient.updateWebhook(ctx, org, flow, webhookId, params); err != nil {
+			return diag.FromErr(fmt.Errorf("webhookUpdate failed: %w", err))
+		}
+	}
+	return webhookRead(ctx, d, meta)
+}
+
+func webhookDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	apiClient := meta.(*Client)
+	org, flow, webhookId, err := parseWebhookId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = apiClient.deleteWebhook(ctx, org, flow, webhookId)
+	if err != nil && !IsNotFound(err) {
+		return diag.FromErr(fmt.Errorf("webhookDelete failed: %w", err))
+	}
+	return nil
+}
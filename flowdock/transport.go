@@ -0,0 +1,257 @@
+package flowdock
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultMinWait    = 1 * time.Second
+	defaultMaxWait    = 30 * time.Second
+	defaultTimeout    = 10 * time.Second
+	// longest Retry-After that is waited for, the response is returned instead
+	maxRetryAfter = 5 * time.Minute
+)
+
+// httpSettings are the connection settings of the provider block.
+type httpSettings struct {
+	// timeout of a single attempt
+	Timeout            time.Duration
+	ProxyURL           string
+	CACertFile         string
+	InsecureSkipVerify bool
+}
+
+// newHTTPTransport builds the transport requests are finally sent with. The
+// proxy defaults to the HTTPS_PROXY environment variables when ProxyURL is
+// empty, and CACertFile is trusted on top of the system roots.
+func newHTTPTransport(settings httpSettings) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if settings.ProxyURL != "" {
+		proxy, err := url.Parse(settings.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy_url %q", settings.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: settings.InsecureSkipVerify}
+	if settings.CACertFile != "" {
+		pem, err := ioutil.ReadFile(settings.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read ca_cert_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in ca_cert_file %s", settings.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+type retrySafeKey struct{}
+
+// withSafeRetry marks a non idempotent request as safe to send again, e.g. a
+// POST that adds a user to a flow has the same effect when repeated.
+func withSafeRetry(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), retrySafeKey{}, true))
+}
+
+// retryTransport retries requests that failed with a network error, a 5xx or
+// a 429 response, waiting with a jittered exponential backoff between
+// attempts or as long as the Retry-After header asks for.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	minWait    time.Duration
+	maxWait    time.Duration
+	// timeout of a single attempt, retries get a fresh timeout
+	timeout time.Duration
+}
+
+func newRetryTransport(base http.RoundTripper) *retryTransport {
+	return &retryTransport{
+		base:       base,
+		maxRetries: defaultMaxRetries,
+		minWait:    defaultMinWait,
+		maxWait:    defaultMaxWait,
+		timeout:    defaultTimeout,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := t.rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		var ctx context.Context
+		var cancel context.CancelFunc
+		if t.timeout > 0 {
+			ctx, cancel = context.WithTimeout(req.Context(), t.timeout)
+		} else {
+			ctx, cancel = context.WithCancel(req.Context())
+		}
+		res, err := t.base.RoundTrip(attemptReq.WithContext(ctx))
+
+		retry := attempt < t.maxRetries && t.shouldRetry(req, res, err) &&
+			(req.Body == nil || req.GetBody != nil)
+		var wait time.Duration
+		if retry {
+			wait = t.backoff(attempt, res)
+			retry = canWait(req.Context(), wait)
+		}
+		if !retry {
+			if res != nil {
+				// the attempt context has to live until the body is read
+				res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+			} else {
+				cancel()
+			}
+			return res, err
+		}
+
+		if res != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+			log.Printf("[DEBUG] %s %s returned %d, retrying in %s (%d/%d)",
+				req.Method, req.URL.Path, res.StatusCode, wait, attempt+1, t.maxRetries)
+		} else {
+			log.Printf("[DEBUG] %s %s failed: %s, retrying in %s (%d/%d)",
+				req.Method, req.URL.Path, err, wait, attempt+1, t.maxRetries)
+		}
+		cancel()
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// rewind returns a copy of req with a fresh body for every retry.
+func (t *retryTransport) rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+func (t *retryTransport) shouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	// throttled requests were never processed, so they are always safe to send again
+	if res != nil && res.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if !isIdempotent(req) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	safe, _ := req.Context().Value(retrySafeKey{}).(bool)
+	return safe
+}
+
+// backoff honors Retry-After when the server sent it, otherwise it doubles
+// minWait on every attempt up to maxWait and picks a random wait in the upper
+// half of that window.
+func (t *retryTransport) backoff(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if wait, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+			return wait
+		}
+	}
+	wait := t.minWait << uint(attempt)
+	if wait > t.maxWait || wait <= 0 {
+		wait = t.maxWait
+	}
+	if wait <= 0 {
+		return 0
+	}
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(wait-half)+1))
+}
+
+// canWait fails fast when the server asks for a longer wait than
+// maxRetryAfter or than the time left to the operation.
+func canWait(ctx context.Context, wait time.Duration) bool {
+	if wait > maxRetryAfter {
+		log.Printf("[DEBUG] not retrying, the server asked to wait %s", wait)
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		log.Printf("[DEBUG] not retrying, waiting %s would exceed the deadline", wait)
+		return false
+	}
+	return true
+}
+
+// parseRetryAfter understands both the delay-seconds and the HTTP-date forms.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *cancelOnClose) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
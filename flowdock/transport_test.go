@@ -0,0 +1,151 @@
+package flowdock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRetryTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *httptest.Server) {
+	client, _ := NewClient("apiKey")
+	client.setRetryPolicy(2, time.Millisecond, 5*time.Millisecond)
+	ts := httptest.NewServer(handler)
+	client.URL = ts.URL
+	return client, ts
+}
+
+func Test_retryTransport_Should_Retry_Idempotent_Request_On_Server_Error(t *testing.T) {
+	calls := 0
+	client, ts := newRetryTestClient(t, func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls < 3 {
+			res.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte(flowMockBasic()))
+	})
+	defer ts.Close()
+
+	result, err := client.getFlow(context.Background(), "org", "ops-projects")
+	assert.NoError(t, err)
+	assert.Equal(t, "ops-projects", result.APIName)
+	assert.Equal(t, 3, calls)
+}
+
+func Test_retryTransport_Should_Give_Up_After_Max_Retries(t *testing.T) {
+	calls := 0
+	client, ts := newRetryTestClient(t, func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		res.WriteHeader(http.StatusBadGateway)
+	})
+	defer ts.Close()
+
+	_, err := client.getFlow(context.Background(), "org", "ops-projects")
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func Test_retryTransport_Should_Not_Retry_Unsafe_Post_On_Server_Error(t *testing.T) {
+	calls := 0
+	client, ts := newRetryTestClient(t, func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		res.WriteHeader(http.StatusInternalServerError)
+	})
+	defer ts.Close()
+
+	_, err := client.inviteNewUser(context.Background(), "xxxxxxx@fairfaxmedia.co.nz", "message", "org", "flow")
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func Test_retryTransport_Should_Retry_Safe_Post_With_Same_Body(t *testing.T) {
+	var ids []string
+	client, ts := newRetryTestClient(t, func(res http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		ids = append(ids, req.PostForm.Get("id"))
+		if len(ids) == 1 {
+			res.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	})
+	defer ts.Close()
+
+	err := client.addUserToFlow(context.Background(), "org", "flow", "123456")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"123456", "123456"}, ids)
+}
+
+func Test_retryTransport_Should_Retry_Any_Request_When_Rate_Limited(t *testing.T) {
+	calls := 0
+	client, ts := newRetryTestClient(t, func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls == 1 {
+			res.Header().Set("Retry-After", "0")
+			res.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		res.WriteHeader(http.StatusCreated)
+		res.Write([]byte(flowMockBasic()))
+	})
+	defer ts.Close()
+
+	_, err := client.createFlow(context.Background(), "org", "Ops Projects")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func Test_retryTransport_Should_Fail_Fast_When_Retry_After_Is_Too_Long(t *testing.T) {
+	calls := 0
+	retryAfter := "3600"
+	client, ts := newRetryTestClient(t, func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		res.Header().Set("Retry-After", retryAfter)
+		res.WriteHeader(http.StatusTooManyRequests)
+	})
+	defer ts.Close()
+
+	start := time.Now()
+	_, err := client.getFlow(context.Background(), "org", "ops-projects")
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	// longer than the time left to the operation
+	retryAfter = "10"
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = client.getFlow(ctx, "org", "ops-projects")
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls)
+	assert.True(t, time.Since(start) < time.Second, "the request waited for Retry-After")
+}
+
+func Test_parseRetryAfter_Should_Accept_Seconds_And_Http_Dates(t *testing.T) {
+	wait, ok := parseRetryAfter("7")
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, wait)
+
+	wait, ok = parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.True(t, wait > 50*time.Second && wait <= time.Minute)
+
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+}
+
+func Test_backoff_Should_Stay_Within_Doubling_Window(t *testing.T) {
+	transport := &retryTransport{minWait: time.Second, maxWait: 5 * time.Second}
+	for i := 0; i < 20; i++ {
+		wait := transport.backoff(1, nil)
+		assert.True(t, wait >= time.Second && wait <= 2*time.Second)
+		wait = transport.backoff(5, nil)
+		assert.True(t, wait >= 2500*time.Millisecond && wait <= 5*time.Second)
+	}
+	wait := transport.backoff(0, &http.Response{Header: http.Header{"Retry-After": {"42"}}})
+	assert.Equal(t, 42*time.Second, wait)
+}
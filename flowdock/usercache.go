@@ -0,0 +1,77 @@
+package flowdock
+
+import (
+	"sync"
+	"time"
+)
+
+const defaultUserCacheTTL = 5 * time.Minute
+
+// userCache keeps the user list of every organization a Client has looked
+// at, so that resources looking up users by email or ID during the same run
+// don't download the whole list again.
+type userCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*userCacheEntry
+}
+
+// each org has its own lock so that concurrent lookups in the same org wait
+// for a single download while other orgs aren't blocked
+type userCacheEntry struct {
+	mu      sync.Mutex
+	users   []User
+	fetched time.Time
+}
+
+func newUserCache(ttl time.Duration) *userCache {
+	return &userCache{ttl: ttl, entries: make(map[string]*userCacheEntry)}
+}
+
+func (cache *userCache) setTTL(ttl time.Duration) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.ttl = ttl
+}
+
+func (cache *userCache) entry(org string) (*userCacheEntry, time.Duration) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	entry, ok := cache.entries[org]
+	if !ok {
+		entry = &userCacheEntry{}
+		cache.entries[org] = entry
+	}
+	return entry, cache.ttl
+}
+
+// get returns the cached users of org, calling fetch when they are missing
+// or older than the TTL. A TTL of 0 disables caching.
+func (cache *userCache) get(org string, fetch func() ([]User, error)) ([]User, error) {
+	entry, ttl := cache.entry(org)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if ttl > 0 && entry.users != nil && time.Since(entry.fetched) < ttl {
+		return append([]User(nil), entry.users...), nil
+	}
+	users, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = []User{}
+	}
+	entry.users = users
+	entry.fetched = time.Now()
+	return append([]User(nil), users...), nil
+}
+
+// invalidate drops the cached users of org, it has to be called after every
+// request that changes the organization's members.
+func (cache *userCache) invalidate(org string) {
+	entry, _ := cache.entry(org)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	entry.users = nil
+}
@@ -0,0 +1,84 @@
+package flowdock
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newUserCacheTestClient(t *testing.T, calls *int32) (*Client, *httptest.Server) {
+	output, _ := json.Marshal([]User{
+		{ID: 123456, Email: "xxxxx@fairfaxmedia.co.nz"},
+		{ID: 654321, Email: "yyyyy@fairfaxmedia.co.nz"},
+	})
+	client, _ := NewClient("apiKey")
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.Method == "GET" {
+			atomic.AddInt32(calls, 1)
+			// give concurrent lookups a chance to pile up
+			time.Sleep(10 * time.Millisecond)
+			res.WriteHeader(http.StatusOK)
+			res.Write(output)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}))
+	client.URL = ts.URL
+	return client, ts
+}
+
+func Test_userCache_Should_Download_Org_Users_Once_For_Concurrent_Lookups(t *testing.T) {
+	var calls int32
+	client, ts := newUserCacheTestClient(t, &calls)
+	defer ts.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := client.getUserIdByEmail(context.Background(), "org", "yyyyy@fairfaxmedia.co.nz")
+			assert.NoError(t, err)
+			assert.Equal(t, "654321", id)
+		}()
+	}
+	wg.Wait()
+
+	user, err := client.getOrgUserById(context.Background(), "org", "123456")
+	assert.NoError(t, err)
+	assert.Equal(t, "xxxxx@fairfaxmedia.co.nz", user.Email)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func Test_userCache_Should_Download_Again_After_Mutation(t *testing.T) {
+	var calls int32
+	client, ts := newUserCacheTestClient(t, &calls)
+	defer ts.Close()
+
+	client.listOrgUsers(context.Background(), "org")
+	client.listOrgUsers(context.Background(), "other-org")
+	client.deleteUserFromOrg(context.Background(), "org", "123456")
+	client.listOrgUsers(context.Background(), "org")
+	client.listOrgUsers(context.Background(), "other-org")
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func Test_userCache_Should_Not_Cache_When_TTL_Is_Zero(t *testing.T) {
+	var calls int32
+	client, ts := newUserCacheTestClient(t, &calls)
+	defer ts.Close()
+	client.setUserCacheTTL(0)
+
+	client.listOrgUsers(context.Background(), "org")
+	client.listOrgUsers(context.Background(), "org")
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
@@ -3,11 +3,12 @@ package main
 import (
 	"terraform-provider-flowdock/flowdock"
 
-	"github.com/hashicorp/terraform-plugin-sdk/plugin"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
 )
 
 func main() {
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: flowdock.Provider,
 	})
+	flowdock.LogClientStats()
 }